@@ -2,13 +2,40 @@ package aorm
 
 import (
 	"database/sql" //只需导入你需要的驱动即可
+	"fmt"
 	"github.com/tangpanqing/aorm/base"
 	"github.com/tangpanqing/aorm/builder"
+	"github.com/tangpanqing/aorm/driver"
 	"github.com/tangpanqing/aorm/migrator"
 )
 
-//Open 开始一个数据库连接
+//Open 开始一个数据库连接,方言由驱动名推断,支持 pgx,sqlite,sqlserver 等别名
 func Open(driverName string, dataSourceName string) (*base.Db, error) {
+	return open(driverName, dataSourceName, driver.ResolveName(driverName))
+}
+
+//OpenWithDialect 开始一个数据库连接,并指定方言,用于驱动名无法推断方言的情况
+func OpenWithDialect(driverName string, dataSourceName string, dialectName string) (*base.Db, error) {
+	if _, ok := driver.Lookup(dialectName); !ok {
+		return &base.Db{}, fmt.Errorf("%w: %s", ErrUnknownDialect, dialectName)
+	}
+
+	return open(driverName, dataSourceName, driver.ResolveName(dialectName))
+}
+
+//OpenDB 使用已有的连接池,并指定方言
+func OpenDB(sqlDB *sql.DB, dialectName string) (*base.Db, error) {
+	if _, ok := driver.Lookup(dialectName); !ok {
+		return &base.Db{}, fmt.Errorf("%w: %s", ErrUnknownDialect, dialectName)
+	}
+
+	return &base.Db{
+		Driver: driver.ResolveName(dialectName),
+		SqlDB:  sqlDB,
+	}, nil
+}
+
+func open(driverName string, dataSourceName string, dialectName string) (*base.Db, error) {
 	sqlDB, err := sql.Open(driverName, dataSourceName)
 	if err != nil {
 		return &base.Db{}, err
@@ -20,11 +47,31 @@ func Open(driverName string, dataSourceName string) (*base.Db, error) {
 	}
 
 	return &base.Db{
-		Driver: driverName,
+		Driver: dialectName,
 		SqlDB:  sqlDB,
 	}, nil
 }
 
+var ErrNotFound = builder.ErrNotFound
+var ErrMissingTable = builder.ErrMissingTable
+var ErrMissingAlias = builder.ErrMissingAlias
+var ErrEmptyBatch = builder.ErrEmptyBatch
+var ErrUnregisteredField = builder.ErrUnregisteredField
+var ErrMissingPrimaryKey = builder.ErrMissingPrimaryKey
+var ErrNotSupported = builder.ErrNotSupported
+var ErrMissingParam = builder.ErrMissingParam
+var ErrInvalidPageSize = builder.ErrInvalidPageSize
+var ErrMissingOrder = builder.ErrMissingOrder
+var ErrInvalidCursor = builder.ErrInvalidCursor
+var ErrNoFields = builder.ErrNoFields
+var ErrMixedColumns = builder.ErrMixedColumns
+var ErrMissingConflictKey = builder.ErrMissingConflictKey
+var ErrDuplicateKey = builder.ErrDuplicateKey
+var ErrForeignKeyViolation = builder.ErrForeignKeyViolation
+var ErrNotNullViolation = builder.ErrNotNullViolation
+var ErrDeadlock = builder.ErrDeadlock
+var ErrUnknownDialect = driver.ErrUnknownDialect
+
 func Store(destList ...interface{}) {
 	builder.Store(destList...)
 }
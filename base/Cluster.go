@@ -0,0 +1,133 @@
+package base
+
+import (
+	"database/sql"
+	"math/rand"
+	"sync/atomic"
+)
+
+// ReplicaLink 支持读写分离的连接,查询语句可以路由到从库
+type ReplicaLink interface {
+	Replica() Link
+}
+
+// ReplicaPolicy 从库选择策略
+type ReplicaPolicy interface {
+	Choose(replicas []*sql.DB) *sql.DB
+}
+
+// RoundRobinPolicy 轮询
+type RoundRobinPolicy struct {
+	counter uint64
+}
+
+func (p *RoundRobinPolicy) Choose(replicas []*sql.DB) *sql.DB {
+	n := atomic.AddUint64(&p.counter, 1)
+	return replicas[(n-1)%uint64(len(replicas))]
+}
+
+// RandomPolicy 随机
+type RandomPolicy struct{}
+
+func (p *RandomPolicy) Choose(replicas []*sql.DB) *sql.DB {
+	return replicas[rand.Intn(len(replicas))]
+}
+
+// LeastConnPolicy 使用中连接数最少
+type LeastConnPolicy struct{}
+
+func (p *LeastConnPolicy) Choose(replicas []*sql.DB) *sql.DB {
+	chosen := replicas[0]
+	inUse := chosen.Stats().InUse
+	for i := 1; i < len(replicas); i++ {
+		if n := replicas[i].Stats().InUse; n < inUse {
+			chosen = replicas[i]
+			inUse = n
+		}
+	}
+	return chosen
+}
+
+// Cluster 一主多从,写入与事务使用主库,查询使用从库
+type Cluster struct {
+	*Db
+	Replicas []*sql.DB
+	Policy   ReplicaPolicy
+
+	replicaMap map[*sql.DB]*Db
+}
+
+// NewCluster 创建一主多从的连接,策略为空时使用轮询,从库沿用主库的调试模式,日志与预处理语句缓存
+func NewCluster(primary *Db, replicas []*sql.DB, policy ReplicaPolicy) *Cluster {
+	if policy == nil {
+		policy = &RoundRobinPolicy{}
+	}
+
+	cacheSize := primary.StmtCacheStats().Size
+	replicaMap := make(map[*sql.DB]*Db)
+	for i := 0; i < len(replicas); i++ {
+		replica := &Db{
+			Driver:    primary.Driver,
+			DebugMode: primary.DebugMode,
+			SqlDB:     replicas[i],
+			Logger:    primary.Logger,
+		}
+		replica.SetStmtCacheSize(cacheSize)
+		replicaMap[replicas[i]] = replica
+	}
+
+	return &Cluster{
+		Db:         primary,
+		Replicas:   replicas,
+		Policy:     policy,
+		replicaMap: replicaMap,
+	}
+}
+
+// Replica 按策略选择一个从库,没有从库时使用主库
+func (c *Cluster) Replica() Link {
+	if len(c.Replicas) == 0 {
+		return c.Db
+	}
+
+	if replica, ok := c.replicaMap[c.Policy.Choose(c.Replicas)]; ok {
+		return replica
+	}
+
+	return c.Db
+}
+
+//SetDebugMode 设置主库与全部从库的调试模式
+func (c *Cluster) SetDebugMode(debugMode bool) {
+	c.Db.SetDebugMode(debugMode)
+	for _, replica := range c.replicaMap {
+		replica.SetDebugMode(debugMode)
+	}
+}
+
+//SetLogger 设置主库与全部从库的日志
+func (c *Cluster) SetLogger(logger Logger) {
+	c.Db.SetLogger(logger)
+	for _, replica := range c.replicaMap {
+		replica.SetLogger(logger)
+	}
+}
+
+//SetStmtCacheSize 设置主库与全部从库的预处理语句缓存数量
+func (c *Cluster) SetStmtCacheSize(n int) {
+	c.Db.SetStmtCacheSize(n)
+	for _, replica := range c.replicaMap {
+		replica.SetStmtCacheSize(n)
+	}
+}
+
+// Close 关闭主库与全部从库
+func (c *Cluster) Close() error {
+	err := c.Db.Close()
+	for _, replica := range c.replicaMap {
+		if errReplica := replica.Close(); errReplica != nil && err == nil {
+			err = errReplica
+		}
+	}
+	return err
+}
@@ -1,7 +1,11 @@
 package base
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
 	"time"
 )
 
@@ -9,23 +13,75 @@ type Db struct {
 	Driver    string
 	DebugMode bool
 	SqlDB     *sql.DB
+	Logger    Logger
+
+	stmtCache *StmtCache
+	version   *serverVersion
+	cacheLock sync.RWMutex
 }
 
 //Close 关闭
 func (db *Db) Close() error {
+	if stmtCache := db.getStmtCache(); stmtCache != nil {
+		stmtCache.Close()
+	}
 	return db.SqlDB.Close()
 }
 
 //Begin 开始一个事务
-func (db *Db) Begin() *Tx {
-	SqlTx, _ := db.SqlDB.Begin()
+func (db *Db) Begin() (*Tx, error) {
+	return db.BeginTx(context.Background(), nil)
+}
+
+//BeginTx 开始一个事务,可以设置隔离级别与只读
+func (db *Db) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	SqlTx, err := db.SqlDB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Tx{
 		driver:    db.Driver,
 		debugMode: db.DebugMode,
+		logger:    db.GetLogger(),
+		stmtCache: db.getStmtCache(),
+		version:   db.getVersion(),
 
 		sqlTx: SqlTx,
+	}, nil
+}
+
+//Transaction 在事务中执行,返回nil则提交,返回错误或者panic则回滚
+func (db *Db) Transaction(fn func(tx *Tx) error) error {
+	return db.TransactionTx(context.Background(), nil, fn)
+}
+
+//TransactionTx 在事务中执行,可以设置上下文,隔离级别与只读
+func (db *Db) TransactionTx(ctx context.Context, opts *sql.TxOptions, fn func(tx *Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	panicked := true
+	defer func() {
+		if panicked {
+			tx.Rollback()
+		}
+	}()
+
+	err = fn(tx)
+	panicked = false
+
+	//钩子出错等情况下事务可能已经回滚
+	if err != nil {
+		if errRollback := tx.Rollback(); errRollback != nil && !errors.Is(errRollback, sql.ErrTxDone) {
+			return fmt.Errorf("%w; rollback: %v", err, errRollback)
+		}
+		return err
 	}
+
+	return tx.Commit()
 }
 
 //SetDebugMode 获取调试模式
@@ -53,11 +109,77 @@ func (db *Db) Stats() sql.DBStats {
 	return db.SqlDB.Stats()
 }
 
+//SetStmtCacheSize 设置预处理语句缓存的数量,为0时不缓存
+func (db *Db) SetStmtCacheSize(n int) {
+	db.cacheLock.Lock()
+	defer db.cacheLock.Unlock()
+
+	if db.stmtCache != nil {
+		db.stmtCache.Close()
+		db.stmtCache = nil
+	}
+
+	if n > 0 {
+		db.stmtCache = NewStmtCache(db.SqlDB, n)
+	}
+}
+
+//StmtCacheStats 获取预处理语句缓存的命中统计
+func (db *Db) StmtCacheStats() StmtCacheStats {
+	stmtCache := db.getStmtCache()
+	if stmtCache == nil {
+		return StmtCacheStats{}
+	}
+	return stmtCache.Stats()
+}
+
+//ServerVersion 获取数据库版本,同一个连接池只查询一次
+func (db *Db) ServerVersion(ctx context.Context, query string) (string, error) {
+	return db.getVersion().get(ctx, db, query)
+}
+
+//getVersion 获取数据库版本的缓存,第一次使用时创建
+func (db *Db) getVersion() *serverVersion {
+	db.cacheLock.Lock()
+	defer db.cacheLock.Unlock()
+
+	if db.version == nil {
+		db.version = &serverVersion{}
+	}
+	return db.version
+}
+
+//getStmtCache 获取当前的预处理语句缓存,未开启时为nil
+func (db *Db) getStmtCache() *StmtCache {
+	db.cacheLock.RLock()
+	defer db.cacheLock.RUnlock()
+
+	return db.stmtCache
+}
+
 //GetDebugMode 获取调试模式
 func (db *Db) GetDebugMode() bool {
 	return db.DebugMode
 }
 
+//SetLogger 设置日志
+func (db *Db) SetLogger(logger Logger) {
+	db.Logger = logger
+}
+
+//GetLogger 获取日志,没有设置时,调试模式下记录全部sql,否则不记录sql,只输出迁移失败等错误
+func (db *Db) GetLogger() Logger {
+	if db.Logger != nil {
+		return db.Logger
+	}
+
+	if db.DebugMode {
+		return debugLogger
+	}
+
+	return defaultLogger
+}
+
 func (db *Db) DriverName() string {
 	return db.Driver
 }
@@ -77,3 +199,33 @@ func (db *Db) Query(query string, args ...interface{}) (*sql.Rows, error) {
 func (db *Db) QueryRow(query string, args ...interface{}) *sql.Row {
 	return db.SqlDB.QueryRow(query, args...)
 }
+
+func (db *Db) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.SqlDB.ExecContext(ctx, query, args...)
+}
+
+func (db *Db) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return db.SqlDB.PrepareContext(ctx, query)
+}
+
+func (db *Db) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.SqlDB.QueryContext(ctx, query, args...)
+}
+
+func (db *Db) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.SqlDB.QueryRowContext(ctx, query, args...)
+}
+
+//PrepareCached 预处理语句,开启缓存时从缓存获取,使用完需要调用 release
+func (db *Db) PrepareCached(ctx context.Context, query string) (*sql.Stmt, func(), error) {
+	stmtCache := db.getStmtCache()
+	if stmtCache == nil {
+		stmt, err := db.SqlDB.PrepareContext(ctx, query)
+		if err != nil {
+			return nil, nil, err
+		}
+		return stmt, func() { stmt.Close() }, nil
+	}
+
+	return stmtCache.Get(ctx, query)
+}
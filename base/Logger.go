@@ -0,0 +1,101 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+)
+
+// LogLevel 日志级别
+type LogLevel int
+
+const (
+	LogSilent LogLevel = iota
+	LogError
+	LogWarn
+	LogInfo
+)
+
+// QueryLog 一次sql执行的记录
+type QueryLog struct {
+	Driver       string
+	Sql          string
+	Args         []interface{}
+	Duration     time.Duration
+	RowsAffected int64 //查询语句无法获取影响行数,值为 -1
+	Err          error
+	Debug        bool //来自开启调试的操作
+}
+
+// Logger 日志接口,可以替换为自己的实现
+type Logger interface {
+	LogQuery(ctx context.Context, entry QueryLog)
+	Log(ctx context.Context, level LogLevel, msg string)
+}
+
+// StdLogger 默认日志,基于标准库 log
+type StdLogger struct {
+	Level         LogLevel
+	SlowThreshold time.Duration //慢查询阈值,为0时不记录慢查询
+	Writer        *log.Logger
+}
+
+// NewLogger 创建默认日志
+func NewLogger(w io.Writer, level LogLevel, slowThreshold time.Duration) *StdLogger {
+	return &StdLogger{
+		Level:         level,
+		SlowThreshold: slowThreshold,
+		Writer:        log.New(w, "[aorm] ", log.LstdFlags),
+	}
+}
+
+// LogQuery 记录sql,出错记为error,超过阈值记为slow,其他记为info
+func (l *StdLogger) LogQuery(ctx context.Context, entry QueryLog) {
+	str := fmt.Sprintf("[%s] [%.3fms] [rows:%d] %s %v", entry.Driver, float64(entry.Duration.Microseconds())/1000, entry.RowsAffected, entry.Sql, entry.Args)
+
+	if entry.Err != nil {
+		if l.Level >= LogError || entry.Debug {
+			l.Writer.Println("[error] " + str + " " + entry.Err.Error())
+		}
+	} else if l.SlowThreshold > 0 && entry.Duration > l.SlowThreshold {
+		if l.Level >= LogWarn {
+			l.Writer.Println("[slow] " + str)
+		}
+	} else if l.Level >= LogInfo || entry.Debug {
+		l.Writer.Println("[info] " + str)
+	}
+}
+
+// Log 记录一般信息
+func (l *StdLogger) Log(ctx context.Context, level LogLevel, msg string) {
+	if level > l.Level || level == LogSilent {
+		return
+	}
+
+	switch level {
+	case LogError:
+		l.Writer.Println("[error] " + msg)
+	case LogWarn:
+		l.Writer.Println("[warn] " + msg)
+	default:
+		l.Writer.Println("[info] " + msg)
+	}
+}
+
+// quietLogger 不记录sql,只有开启调试的操作例外,迁移失败等一般信息仍按级别输出
+type quietLogger struct {
+	*StdLogger
+}
+
+func (l quietLogger) LogQuery(ctx context.Context, entry QueryLog) {
+	if entry.Debug {
+		l.StdLogger.LogQuery(ctx, entry)
+	}
+}
+
+//defaultLogger 没有设置日志且未开启调试时使用,不输出sql,只输出迁移失败等错误
+var defaultLogger Logger = quietLogger{NewLogger(os.Stdout, LogError, 0)}
+var debugLogger = NewLogger(os.Stdout, LogInfo, 0)
@@ -0,0 +1,32 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestQuietLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := quietLogger{NewLogger(&buf, LogError, 0)}
+	ctx := context.Background()
+
+	logger.LogQuery(ctx, QueryLog{Sql: "SELECT 1", Err: errors.New("query failed")})
+	logger.Log(ctx, LogInfo, "created table")
+	if buf.Len() != 0 {
+		t.Fatalf("expected no output, got %q", buf.String())
+	}
+
+	logger.Log(ctx, LogError, "migrate failed")
+	if !strings.Contains(buf.String(), "[error] migrate failed") {
+		t.Fatalf("error not logged, got %q", buf.String())
+	}
+
+	buf.Reset()
+	logger.LogQuery(ctx, QueryLog{Sql: "SELECT 2", Debug: true})
+	if !strings.Contains(buf.String(), "SELECT 2") {
+		t.Fatalf("debug query not logged, got %q", buf.String())
+	}
+}
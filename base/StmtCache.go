@@ -0,0 +1,181 @@
+package base
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// StmtCacheLink 支持语句缓存的连接,使用完语句需要调用 release
+type StmtCacheLink interface {
+	PrepareCached(ctx context.Context, query string) (stmt *sql.Stmt, release func(), err error)
+}
+
+// StmtCacheStats 语句缓存的统计信息
+type StmtCacheStats struct {
+	Size      int
+	Len       int
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+type stmtCacheEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// StmtCache 预处理语句的LRU缓存,以最终sql为键
+type StmtCache struct {
+	mu    sync.Mutex
+	db    *sql.DB
+	size  int
+	list  *list.List
+	items map[string]*list.Element
+	//closed 已关闭,例如被 SetStmtCacheSize 替换,之后预处理的语句不再缓存
+	closed bool
+
+	hits      uint64
+	misses    uint64
+	evictions uint64
+}
+
+// NewStmtCache 创建语句缓存
+func NewStmtCache(db *sql.DB, size int) *StmtCache {
+	return &StmtCache{
+		db:    db,
+		size:  size,
+		list:  list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get 获取缓存的语句,没有则预处理后缓存,语句被淘汰时,等全部使用者 release 后才关闭
+func (c *StmtCache) Get(ctx context.Context, query string) (*sql.Stmt, func(), error) {
+	c.mu.Lock()
+	if el, ok := c.items[query]; ok {
+		c.hits++
+		entry := c.use(el)
+		c.mu.Unlock()
+		return entry.stmt, c.releaseFunc(entry), nil
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	stmt, err := c.db.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	//预处理期间缓存已关闭,语句不再缓存,使用完直接关闭
+	if c.closed {
+		return stmt, closeFunc(stmt), nil
+	}
+
+	//并发时可能已被其他调用者缓存
+	if el, ok := c.items[query]; ok {
+		stmt.Close()
+		entry := c.use(el)
+		return entry.stmt, c.releaseFunc(entry), nil
+	}
+
+	entry := &stmtCacheEntry{query: query, stmt: stmt, refs: 1}
+	c.items[query] = c.list.PushFront(entry)
+
+	for c.list.Len() > c.size {
+		c.evict(c.list.Back())
+	}
+
+	return entry.stmt, c.releaseFunc(entry), nil
+}
+
+// Lookup 获取缓存的语句,没有时不预处理,用于事务中,避免在连接池上再占用一个连接
+func (c *StmtCache) Lookup(query string) (*sql.Stmt, func(), bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[query]
+	if !ok {
+		c.misses++
+		return nil, nil, false
+	}
+
+	c.hits++
+	entry := c.use(el)
+	return entry.stmt, c.releaseFunc(entry), true
+}
+
+// Stats 获取统计信息
+func (c *StmtCache) Stats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return StmtCacheStats{
+		Size:      c.size,
+		Len:       c.list.Len(),
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+}
+
+// Close 清空缓存,之后获取的语句不再缓存
+func (c *StmtCache) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	for c.list.Len() > 0 {
+		c.evict(c.list.Back())
+	}
+}
+
+func (c *StmtCache) use(el *list.Element) *stmtCacheEntry {
+	c.list.MoveToFront(el)
+	entry := el.Value.(*stmtCacheEntry)
+	entry.refs++
+	return entry
+}
+
+func (c *StmtCache) evict(el *list.Element) {
+	entry := el.Value.(*stmtCacheEntry)
+	c.list.Remove(el)
+	delete(c.items, entry.query)
+	c.evictions++
+
+	entry.evicted = true
+	if entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+//closeFunc 没有缓存的语句,release 时关闭
+func closeFunc(stmt *sql.Stmt) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			stmt.Close()
+		})
+	}
+}
+
+func (c *StmtCache) releaseFunc(entry *stmtCacheEntry) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			entry.refs--
+			if entry.evicted && entry.refs == 0 {
+				entry.stmt.Close()
+			}
+		})
+	}
+}
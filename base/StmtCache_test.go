@@ -0,0 +1,45 @@
+package base
+
+import (
+	"context"
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"testing"
+)
+
+func TestStmtCacheClosed(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	cache := NewStmtCache(db, 2)
+	stmt, release, err := cache.Get(context.Background(), "SELECT 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	//关闭后缓存的语句随之关闭,新的语句不再缓存,release 时关闭
+	cache.Close()
+	if _, err = stmt.Exec(); err == nil {
+		t.Fatal("cached stmt should be closed with the cache")
+	}
+
+	stmt, release, err = cache.Get(context.Background(), "SELECT 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cache.Stats().Len != 0 {
+		t.Fatalf("closed cache should not keep stmt, len %d", cache.Stats().Len)
+	}
+	if _, err = stmt.Exec(); err != nil {
+		t.Fatal(err)
+	}
+
+	release()
+	if _, err = stmt.Exec(); err == nil {
+		t.Fatal("uncached stmt should be closed on release")
+	}
+}
@@ -1,11 +1,23 @@
 package base
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/tangpanqing/aorm/driver"
+	"strconv"
+)
 
 type Tx struct {
 	driver    string
 	debugMode bool
+	logger    Logger
 	sqlTx     *sql.Tx
+	stmtCache *StmtCache
+	version   *serverVersion
+
+	//嵌套事务的层级,用于生成保存点名称
+	savepointId int
 }
 
 //GetDebugMode 获取调试状态
@@ -13,6 +25,11 @@ func (tx *Tx) GetDebugMode() bool {
 	return tx.debugMode
 }
 
+//GetLogger 获取日志
+func (tx *Tx) GetLogger() Logger {
+	return tx.logger
+}
+
 func (tx *Tx) DriverName() string {
 	return tx.driver
 }
@@ -33,6 +50,49 @@ func (tx *Tx) QueryRow(query string, args ...interface{}) *sql.Row {
 	return tx.sqlTx.QueryRow(query, args...)
 }
 
+func (tx *Tx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return tx.sqlTx.ExecContext(ctx, query, args...)
+}
+
+func (tx *Tx) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return tx.sqlTx.PrepareContext(ctx, query)
+}
+
+func (tx *Tx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.sqlTx.QueryContext(ctx, query, args...)
+}
+
+func (tx *Tx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return tx.sqlTx.QueryRowContext(ctx, query, args...)
+}
+
+//PrepareCached 预处理语句,缓存中有时通过 tx.Stmt 绑定到当前事务,没有时在事务中预处理,不写入缓存
+func (tx *Tx) PrepareCached(ctx context.Context, query string) (*sql.Stmt, func(), error) {
+	if tx.stmtCache != nil {
+		if stmt, release, ok := tx.stmtCache.Lookup(query); ok {
+			txStmt := tx.sqlTx.StmtContext(ctx, stmt)
+			return txStmt, func() {
+				txStmt.Close()
+				release()
+			}, nil
+		}
+	}
+
+	stmt, err := tx.sqlTx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, nil, err
+	}
+	return stmt, func() { stmt.Close() }, nil
+}
+
+//ServerVersion 获取数据库版本,与连接池共用缓存,没有缓存时在事务中查询
+func (tx *Tx) ServerVersion(ctx context.Context, query string) (string, error) {
+	if tx.version == nil {
+		tx.version = &serverVersion{}
+	}
+	return tx.version.get(ctx, tx, query)
+}
+
 func (tx *Tx) Rollback() error {
 	return tx.sqlTx.Rollback()
 }
@@ -40,3 +100,65 @@ func (tx *Tx) Rollback() error {
 func (tx *Tx) Commit() error {
 	return tx.sqlTx.Commit()
 }
+
+//savepointDialect 保存点语句,方言没有实现时按 Flavor 处理
+func (tx *Tx) savepointDialect() driver.SavepointDialect {
+	return driver.GetSavepointDialect(driver.GetDialect(tx.driver))
+}
+
+//execSavepoint 执行保存点语句,为空时忽略
+func (tx *Tx) execSavepoint(query string) error {
+	if query == "" {
+		return nil
+	}
+
+	_, err := tx.sqlTx.Exec(query)
+	return err
+}
+
+//Savepoint 设置保存点, Mssql使用 SAVE TRANSACTION
+func (tx *Tx) Savepoint(name string) error {
+	return tx.execSavepoint(tx.savepointDialect().Savepoint(name))
+}
+
+//RollbackTo 回滚到保存点, Mssql使用 ROLLBACK TRANSACTION
+func (tx *Tx) RollbackTo(name string) error {
+	return tx.execSavepoint(tx.savepointDialect().RollbackTo(name))
+}
+
+//ReleaseSavepoint 释放保存点, Mssql没有此操作,直接忽略
+func (tx *Tx) ReleaseSavepoint(name string) error {
+	return tx.execSavepoint(tx.savepointDialect().ReleaseSavepoint(name))
+}
+
+//Transaction 嵌套事务,通过保存点实现,返回错误或者panic时回滚到保存点
+func (tx *Tx) Transaction(fn func(tx *Tx) error) (err error) {
+	tx.savepointId++
+	name := "aorm_sp_" + strconv.Itoa(tx.savepointId)
+	defer func() {
+		tx.savepointId--
+	}()
+
+	if err = tx.Savepoint(name); err != nil {
+		return err
+	}
+
+	panicked := true
+	defer func() {
+		if panicked {
+			tx.RollbackTo(name)
+		}
+	}()
+
+	err = fn(tx)
+	panicked = false
+
+	if err != nil {
+		if errRollback := tx.RollbackTo(name); errRollback != nil {
+			return fmt.Errorf("%w; rollback to savepoint: %v", err, errRollback)
+		}
+		return err
+	}
+
+	return tx.ReleaseSavepoint(name)
+}
@@ -0,0 +1,55 @@
+package base
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// VersionLink 可以获取数据库版本的连接,版本按连接池缓存
+type VersionLink interface {
+	ServerVersion(ctx context.Context, query string) (string, error)
+}
+
+//serverVersion 数据库版本的缓存,同一个连接池及其事务共用
+type serverVersion struct {
+	mu      sync.Mutex
+	loaded  bool
+	version string
+}
+
+//get 获取版本,没有缓存时通过 link 查询,并记录日志
+//查询时不持有锁,否则事务占用唯一的连接时,连接池上的查询会持有锁等待连接,事务中的查询又等待锁
+func (v *serverVersion) get(ctx context.Context, link Link, query string) (string, error) {
+	v.mu.Lock()
+	if v.loaded {
+		version := v.version
+		v.mu.Unlock()
+		return version, nil
+	}
+	v.mu.Unlock()
+
+	start := time.Now()
+	var version string
+	err := link.QueryRowContext(ctx, query).Scan(&version)
+	link.GetLogger().LogQuery(ctx, QueryLog{
+		Driver:       link.DriverName(),
+		Sql:          query,
+		Duration:     time.Since(start),
+		RowsAffected: -1,
+		Err:          err,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	//并发时以先完成的查询为准
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if !v.loaded {
+		v.loaded = true
+		v.version = version
+	}
+	return v.version, nil
+}
@@ -0,0 +1,47 @@
+package base
+
+import (
+	"context"
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"testing"
+	"time"
+)
+
+func TestServerVersionInTx(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	db := &Db{Driver: "sqlite3", SqlDB: sqlDB}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//连接池上的查询等待事务占用的连接,事务中的查询不能被它阻塞
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	go db.ServerVersion(ctx, "SELECT sqlite_version()")
+	time.Sleep(50 * time.Millisecond)
+
+	version, err := tx.ServerVersion(ctx, "SELECT sqlite_version()")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version == "" {
+		t.Fatal("version should not be empty")
+	}
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	//事务中查询到的版本与连接池共用
+	version2, err := db.ServerVersion(context.Background(), "SELECT 'other'")
+	if err != nil || version2 != version {
+		t.Fatalf("version should be cached, got %q %v", version2, err)
+	}
+}
@@ -1,10 +1,13 @@
 package builder
 
 import (
+	"database/sql"
+	sqlDriver "database/sql/driver"
 	"fmt"
 	"github.com/tangpanqing/aorm/utils"
 	"reflect"
 	"strings"
+	"time"
 )
 
 type GroupItem struct {
@@ -17,6 +20,7 @@ type WhereItem struct {
 	Field  interface{}
 	Opt    string
 	Val    interface{}
+	Logic  string
 }
 
 type SelectItem struct {
@@ -24,6 +28,7 @@ type SelectItem struct {
 	Prefix   []string
 	Field    interface{}
 	FieldNew interface{}
+	Over     *Over
 }
 
 type SelectExpItem struct {
@@ -54,16 +59,28 @@ type JoinCondition struct {
 	Opt                 string
 	FieldOfOtherTable   interface{}
 	AliasOfOtherTable   []string
+	Logic               string
+	Args                []interface{}
 }
 
 //GenWhereItem 产生一个 WhereItem,用作 where 条件里
 func GenWhereItem(field interface{}, opt string, val interface{}, prefix ...string) WhereItem {
-	return WhereItem{prefix, field, opt, val}
+	return WhereItem{prefix, field, opt, val, And}
+}
+
+//GenWhereOrItem 产生一个 WhereItem,用作 where 条件里,与前一个条件以 OR 连接
+func GenWhereOrItem(field interface{}, opt string, val interface{}, prefix ...string) WhereItem {
+	return WhereItem{prefix, field, opt, val, Or}
 }
 
 //GenHavingItem 产生一个 WhereItem,用作 having 条件里
 func GenHavingItem(field interface{}, opt string, val interface{}) WhereItem {
-	return WhereItem{[]string{}, field, opt, val}
+	return WhereItem{[]string{}, field, opt, val, And}
+}
+
+//GenHavingOrItem 产生一个 WhereItem,用作 having 条件里,与前一个条件以 OR 连接
+func GenHavingOrItem(field interface{}, opt string, val interface{}) WhereItem {
+	return WhereItem{[]string{}, field, opt, val, Or}
 }
 
 //GenJoinCondition 产生一个 JoinCondition,用作 join 条件里
@@ -73,40 +90,38 @@ func GenJoinCondition(fieldOfCurrentTable interface{}, opt string, fieldOfOtherT
 		Opt:                 opt,
 		FieldOfOtherTable:   fieldOfOtherTable,
 		AliasOfOtherTable:   aliasOfOtherTable,
+		Logic:               And,
 	}
 }
 
-//getPrefixByField 获取字段前缀,如果传入则使用传入值，默认使用该字段的表名
-func getPrefixByField(valueOf reflect.Value, prefix ...string) string {
-	str := ""
-	if len(prefix) > 0 {
-		str = prefix[0]
-	} else {
-		if reflect.Ptr == valueOf.Kind() {
-			fieldPointer := valueOf.Pointer()
-			tablePointer := getFieldMap(fieldPointer).TablePointer
-
-			tableName := getTableMap(tablePointer)
-			strArr := strings.Split(tableName, ".")
-			str = utils.UnderLine(strArr[len(strArr)-1])
-		} else {
-			//str = fmt.Sprintf("%v", valueOf.Interface())
-		}
+//GenJoinOrCondition 产生一个 JoinCondition,与前一个条件以 OR 连接
+func GenJoinOrCondition(fieldOfCurrentTable interface{}, opt string, fieldOfOtherTable interface{}, aliasOfOtherTable ...string) JoinCondition {
+	return JoinCondition{
+		FieldOfCurrentTable: fieldOfCurrentTable,
+		Opt:                 opt,
+		FieldOfOtherTable:   fieldOfOtherTable,
+		AliasOfOtherTable:   aliasOfOtherTable,
+		Logic:               Or,
 	}
-
-	return str
 }
 
-//getTableNameByTable 根据传入的表信息，获取表名
-func getTableNameByTable(table interface{}) string {
-	valueOf := reflect.ValueOf(table)
-	if reflect.Ptr == valueOf.Kind() {
-		return getTableMap(valueOf.Pointer())
-	} else {
-		return fmt.Sprintf("%v", table)
+//GenJoinRawCondition 产生一个原生表达式的 JoinCondition,例如 GenJoinRawCondition("p.age > ?", 18)
+func GenJoinRawCondition(expr string, args ...interface{}) JoinCondition {
+	return JoinCondition{
+		FieldOfCurrentTable: "",
+		Opt:                 Raw,
+		FieldOfOtherTable:   expr,
+		Logic:               And,
+		Args:                args,
 	}
 }
 
+//getPrefixByTableName 根据表名获取字段前缀
+func getPrefixByTableName(tableName string) string {
+	strArr := strings.Split(tableName, ".")
+	return utils.UnderLine(strArr[len(strArr)-1])
+}
+
 //getTableNameByReflect 反射表名,优先从方法获取,没有方法则从名字获取
 func getTableNameByReflect(typeOf reflect.Type, valueOf reflect.Value) string {
 	method, isSet := typeOf.MethodByName("TableName")
@@ -121,20 +136,6 @@ func getTableNameByReflect(typeOf reflect.Type, valueOf reflect.Value) string {
 	}
 }
 
-//getFieldNameByField 根据传入字段，获取字段名
-func getFieldNameByField(field interface{}) string {
-	return getFieldNameByReflectValue(reflect.ValueOf(field))
-}
-
-//getFieldNameByReflectNew 根据传入字段，获取字段名
-func getFieldNameByReflectValue(valueOfField reflect.Value) string {
-	if reflect.Ptr == valueOfField.Kind() {
-		return getFieldMap(valueOfField.Pointer()).Name
-	} else {
-		return fmt.Sprintf("%v", valueOfField)
-	}
-}
-
 //getFieldNameByStructField
 func getFieldNameByStructField(field reflect.StructField) (string, map[string]string) {
 	key := utils.UnderLine(field.Name)
@@ -151,15 +152,10 @@ func getFieldMapByReflect(destType reflect.Type) map[string][]int {
 	fieldNameMap := make(map[string][]int)
 	for i := 0; i < destType.NumField(); i++ {
 		isMultiLevel := false
-		if "struct" == destType.Field(i).Type.Kind().String() && (destType.Field(i).Type.Name() != "Int" &&
-			destType.Field(i).Type.Name() != "Float" &&
-			destType.Field(i).Type.Name() != "Time" &&
-			destType.Field(i).Type.Name() != "String" &&
-			destType.Field(i).Type.Name() != "Bool") {
+		if reflect.Struct == destType.Field(i).Type.Kind() && !isScalarType(destType.Field(i).Type) {
 			isMultiLevel = true
 		}
 
-		//fmt.Println(isMore, destType, destType.Field(i).Name, destType.Field(i).Type.Name(), destType.Field(i).Type.Kind().String())
 		if isMultiLevel {
 			for j := 0; j < destType.Field(i).Type.NumField(); j++ {
 				fieldNameMap[destType.Field(i).Type.Field(j).Name] = []int{i, j}
@@ -171,6 +167,58 @@ func getFieldMapByReflect(destType reflect.Type) map[string][]int {
 	return fieldNameMap
 }
 
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+var timeType = reflect.TypeOf(time.Time{})
+
+//isScalarType 是否为单个字段的类型, 如 null.Int, sql.NullString, time.Time, 而不是嵌套的结构体
+func isScalarType(typeOf reflect.Type) bool {
+	return typeOf == timeType || reflect.PtrTo(typeOf).Implements(scannerType)
+}
+
+//getValueByReflect 获取字段的值,第二个返回值表示是否有效
+//指针为nil, 或者 null.X, sql.NullX 等 driver.Valuer 返回nil时无效, 其他普通类型总是有效
+func getValueByReflect(valueOf reflect.Value) (interface{}, bool) {
+	if reflect.Ptr == valueOf.Kind() {
+		if valueOf.IsNil() {
+			return nil, false
+		}
+		return getValueByReflect(valueOf.Elem())
+	}
+
+	valuer, ok := valueOf.Interface().(sqlDriver.Valuer)
+	if !ok && valueOf.CanAddr() {
+		valuer, ok = valueOf.Addr().Interface().(sqlDriver.Valuer)
+	}
+
+	if ok {
+		val, err := valuer.Value()
+		if err != nil || val == nil {
+			return nil, false
+		}
+		return val, true
+	}
+
+	return valueOf.Interface(), true
+}
+
+//getWriteValueByReflect 获取写入或更新的字段值, 自增字段为普通类型的零值时不写入
+func getWriteValueByReflect(valueOf reflect.Value, tagMap map[string]string) (interface{}, bool) {
+	if _, ok := tagMap["auto_increment"]; ok {
+		return getWhereValueByReflect(valueOf)
+	}
+
+	return getValueByReflect(valueOf)
+}
+
+//getWhereValueByReflect 获取作为条件的字段值, 普通类型的零值视为未设置
+func getWhereValueByReflect(valueOf reflect.Value) (interface{}, bool) {
+	if reflect.Ptr != valueOf.Kind() && valueOf.IsZero() {
+		return nil, false
+	}
+
+	return getValueByReflect(valueOf)
+}
+
 //getScansAddr 获取赋值的地址
 func getScansAddr(columnNameList []string, fieldNameMap map[string][]int, destValue reflect.Value) []interface{} {
 	var scans []interface{}
@@ -192,34 +240,111 @@ func getScansAddr(columnNameList []string, fieldNameMap map[string][]int, destVa
 }
 
 //genJoinConditionStr 产生关联查询条件
-func genJoinConditionStr(aliasOfCurrentTable string, joinCondition []JoinCondition) (string, []interface{}) {
+func (b *Builder) genJoinConditionStr(aliasOfCurrentTable string, joinCondition []JoinCondition) (string, []interface{}, error) {
+	r := b.getRegistry()
 	var paramList []interface{}
 	var sqlList []string
+	var logicList []string
 	for i := 0; i < len(joinCondition); i++ {
-		fieldNameOfCurrentTable := getFieldNameByField(joinCondition[i].FieldOfCurrentTable)
+		//原生表达式,不需要字段
+		if joinCondition[i].Opt == Raw && joinCondition[i].FieldOfCurrentTable == "" {
+			sqlList = append(sqlList, fmt.Sprintf("%v", joinCondition[i].FieldOfOtherTable))
+			logicList = append(logicList, joinCondition[i].Logic)
+			paramList = append(paramList, joinCondition[i].Args...)
+			continue
+		}
 
-		if aliasOfCurrentTable == "" {
-			aliasOfCurrentTable = getPrefixByField(reflect.ValueOf(joinCondition[i].FieldOfCurrentTable))
+		var fieldOfCurrentTable string
+		if expr, ok := joinCondition[i].FieldOfCurrentTable.(Expr); ok {
+			exprSql, exprParamList, err := expr.toSql(b)
+			if err != nil {
+				return "", paramList, err
+			}
+			fieldOfCurrentTable = exprSql
+			paramList = append(paramList, exprParamList...)
+		} else {
+			fieldNameOfCurrentTable, err := b.getFieldName(joinCondition[i].FieldOfCurrentTable)
+			if err != nil {
+				return "", paramList, err
+			}
+
+			if aliasOfCurrentTable == "" {
+				aliasOfCurrentTable, err = r.getPrefixByField(joinCondition[i].FieldOfCurrentTable)
+				if err != nil {
+					return "", paramList, err
+				}
+			}
+
+			fieldOfCurrentTable = b.quote(aliasOfCurrentTable) + "." + fieldNameOfCurrentTable
+		}
+
+		opt := joinCondition[i].Opt
+		val := joinCondition[i].FieldOfOtherTable
+
+		//与表达式比较
+		if expr, ok := val.(Expr); ok && opt != Raw {
+			if opt == RawEq {
+				opt = Eq
+			}
+
+			exprSql, exprParamList, err := expr.toSql(b)
+			if err != nil {
+				return "", paramList, err
+			}
+
+			sqlList = append(sqlList, fieldOfCurrentTable+" "+opt+" "+exprSql)
+			paramList = append(paramList, exprParamList...)
+			logicList = append(logicList, joinCondition[i].Logic)
+			continue
 		}
 
-		fieldNameOfOtherTable := getFieldNameByField(joinCondition[i].FieldOfOtherTable)
+		switch opt {
+		case RawEq:
+			fieldNameOfOtherTable, err := b.getFieldName(val)
+			if err != nil {
+				return "", paramList, err
+			}
 
-		if joinCondition[i].Opt == RawEq {
-			aliasOfOtherTable := getPrefixByField(reflect.ValueOf(joinCondition[i].FieldOfOtherTable), joinCondition[i].AliasOfOtherTable...)
+			aliasOfOtherTable, err := b.getPrefix(val, joinCondition[i].AliasOfOtherTable...)
+			if err != nil {
+				return "", paramList, err
+			}
 			if aliasOfOtherTable != "" {
 				aliasOfOtherTable += "."
 			}
 
-			sqlList = append(sqlList, aliasOfCurrentTable+"."+fieldNameOfCurrentTable+"="+aliasOfOtherTable+fieldNameOfOtherTable)
-		}
+			sqlList = append(sqlList, fieldOfCurrentTable+"="+aliasOfOtherTable+fieldNameOfOtherTable)
+		case Eq:
+			sqlList = append(sqlList, fieldOfCurrentTable+"=?")
+			paramList = append(paramList, val)
+		case Ne, Gt, Ge, Lt, Le, Like, NotLike:
+			sqlList = append(sqlList, fieldOfCurrentTable+" "+opt+" ?")
+			paramList = append(paramList, val)
+		case In, NotIn:
+			values := toAnyArr(val)
+			var placeholder []string
+			for j := 0; j < len(values); j++ {
+				placeholder = append(placeholder, "?")
+			}
 
-		if joinCondition[i].Opt == Eq {
-			sqlList = append(sqlList, aliasOfCurrentTable+"."+fieldNameOfCurrentTable+"=?")
-			paramList = append(paramList, fieldNameOfOtherTable)
+			sqlList = append(sqlList, fieldOfCurrentTable+" "+opt+" ("+strings.Join(placeholder, ",")+")")
+			paramList = append(paramList, values...)
+		case Between, NotBetween:
+			sqlList = append(sqlList, fieldOfCurrentTable+" "+opt+" (?) AND (?)")
+			paramList = append(paramList, toAnyArr(val)...)
+		case IsNull, IsNotNull:
+			sqlList = append(sqlList, fieldOfCurrentTable+" "+opt)
+		case Raw:
+			sqlList = append(sqlList, fieldOfCurrentTable+" "+fmt.Sprintf("%v", val))
+			paramList = append(paramList, joinCondition[i].Args...)
+		default:
+			continue
 		}
+
+		logicList = append(logicList, joinCondition[i].Logic)
 	}
 
-	return strings.Join(sqlList, " AND "), paramList
+	return joinWhereList(sqlList, logicList), paramList, nil
 }
 
 //toAnyArr 将一个interface抽取成数组
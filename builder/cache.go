@@ -1,66 +1,174 @@
 package builder
 
 import (
+	"fmt"
 	"reflect"
+	"sync"
 )
 
+// FieldInfo 字段信息
 type FieldInfo struct {
-	TablePointer uintptr
-	Name         string
-	TagMap       map[string]string
+	Name   string
+	TagMap map[string]string
+	Model  *ModelInfo
 }
 
-var TableMap = make(map[uintptr]string)
-var FieldMap = make(map[uintptr]FieldInfo)
+// ModelInfo 模型信息,以结构体类型为键
+type ModelInfo struct {
+	Type      reflect.Type
+	TableName string
+	Fields    []*FieldInfo
+}
+
+type fieldKey struct {
+	pointer uintptr
+	typ     reflect.Type
+}
 
-//Store 保存到缓存
+// Registry 模型注册表,可以并发使用,也可以每个实例单独创建
+type Registry struct {
+	mu     sync.RWMutex
+	models map[reflect.Type]*ModelInfo
+	fields map[fieldKey]*FieldInfo
+}
+
+// NewRegistry 创建模型注册表
+func NewRegistry() *Registry {
+	return &Registry{
+		models: make(map[reflect.Type]*ModelInfo),
+		fields: make(map[fieldKey]*FieldInfo),
+	}
+}
+
+// DefaultRegistry 默认的模型注册表, aorm.Store 保存到这里
+var DefaultRegistry = NewRegistry()
+
+//Store 保存到默认注册表
 func Store(destList ...interface{}) {
+	DefaultRegistry.Store(destList...)
+}
+
+//Store 保存结构体指针,之后可以用其字段指针作为字段名
+func (r *Registry) Store(destList ...interface{}) {
 	for i := 0; i < len(destList); i++ {
-		dest := destList[i]
-		valueOf := reflect.ValueOf(dest)
-		typeof := reflect.TypeOf(dest)
-
-		tablePointer := valueOf.Pointer()
-		tableName := getTableNameByReflect(typeof, valueOf)
-		setTableMap(tablePointer, tableName)
-
-		for j := 0; j < valueOf.Elem().NumField(); j++ {
-			fieldPointer := valueOf.Elem().Field(j).Addr().Pointer()
-			key, _ := getFieldNameByStructField(typeof.Elem().Field(j))
-			tag := typeof.Elem().Field(j).Tag.Get("aorm")
-
-			setFieldMap(fieldPointer, FieldInfo{
-				TablePointer: tablePointer,
-				Name:         key,
-				TagMap:       getTagMap(tag),
-			})
+		valueOf := reflect.ValueOf(destList[i])
+		if valueOf.Kind() != reflect.Ptr || valueOf.Elem().Kind() != reflect.Struct {
+			continue
+		}
+
+		model := r.getModel(valueOf.Type(), valueOf)
+
+		r.mu.Lock()
+		for j := 0; j < len(model.Fields); j++ {
+			field := valueOf.Elem().Field(j)
+			r.fields[fieldKey{field.Addr().Pointer(), field.Type()}] = model.Fields[j]
 		}
+		r.mu.Unlock()
 	}
 }
 
-func Comment(field interface{}) string {
-	fieldPointer := reflect.ValueOf(field).Pointer()
-	tagMap := getFieldMap(fieldPointer).TagMap
-	val, ok := tagMap["comment"]
+//getModel 获取模型信息,没有则反射生成
+func (r *Registry) getModel(typeOf reflect.Type, valueOf reflect.Value) *ModelInfo {
+	r.mu.RLock()
+	model, ok := r.models[typeOf]
+	r.mu.RUnlock()
 	if ok {
-		return val
-	} else {
-		return ""
+		return model
+	}
+
+	model = &ModelInfo{
+		Type:      typeOf,
+		TableName: getTableNameByReflect(typeOf, valueOf),
 	}
+
+	for j := 0; j < typeOf.Elem().NumField(); j++ {
+		key, tagMap := getFieldNameByStructField(typeOf.Elem().Field(j))
+		model.Fields = append(model.Fields, &FieldInfo{
+			Name:   key,
+			TagMap: tagMap,
+			Model:  model,
+		})
+	}
+
+	r.mu.Lock()
+	r.models[typeOf] = model
+	r.mu.Unlock()
+
+	return model
 }
 
-func setTableMap(tablePointer uintptr, name string) {
-	TableMap[tablePointer] = name
+//getField 根据字段指针获取字段信息
+func (r *Registry) getField(valueOf reflect.Value) (*FieldInfo, error) {
+	r.mu.RLock()
+	field, ok := r.fields[fieldKey{valueOf.Pointer(), valueOf.Type().Elem()}]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s(%#x), please Store its struct first", ErrUnregisteredField, valueOf.Type(), valueOf.Pointer())
+	}
+
+	return field, nil
 }
 
-func getTableMap(tablePointer uintptr) string {
-	return TableMap[tablePointer]
+//getTableNameByTable 根据传入的表信息，获取表名, 结构体指针按类型获取
+func (r *Registry) getTableNameByTable(table interface{}) (string, error) {
+	valueOf := reflect.ValueOf(table)
+	if reflect.Ptr == valueOf.Kind() {
+		if valueOf.Elem().Kind() != reflect.Struct {
+			return "", fmt.Errorf("%w: unsupported table %s", ErrMissingTable, valueOf.Type())
+		}
+		return r.getModel(valueOf.Type(), valueOf).TableName, nil
+	} else {
+		return fmt.Sprintf("%v", table), nil
+	}
 }
 
-func setFieldMap(fieldPointer uintptr, fieldInfo FieldInfo) {
-	FieldMap[fieldPointer] = fieldInfo
+//getFieldNameByField 根据传入字段，获取字段名
+func (r *Registry) getFieldNameByField(field interface{}) (string, error) {
+	valueOf := reflect.ValueOf(field)
+	if reflect.Ptr == valueOf.Kind() {
+		fieldInfo, err := r.getField(valueOf)
+		if err != nil {
+			return "", err
+		}
+		return fieldInfo.Name, nil
+	} else {
+		return fmt.Sprintf("%v", field), nil
+	}
+}
+
+//getPrefixByField 获取字段前缀,如果传入则使用传入值，默认使用该字段的表名
+func (r *Registry) getPrefixByField(field interface{}, prefix ...string) (string, error) {
+	if len(prefix) > 0 {
+		return prefix[0], nil
+	}
+
+	valueOf := reflect.ValueOf(field)
+	if reflect.Ptr == valueOf.Kind() {
+		fieldInfo, err := r.getField(valueOf)
+		if err != nil {
+			return "", err
+		}
+		return getPrefixByTableName(fieldInfo.Model.TableName), nil
+	}
+
+	return "", nil
 }
 
-func getFieldMap(fieldPointer uintptr) FieldInfo {
-	return FieldMap[fieldPointer]
+func Comment(field interface{}) string {
+	valueOf := reflect.ValueOf(field)
+	if reflect.Ptr != valueOf.Kind() {
+		return ""
+	}
+
+	fieldInfo, err := DefaultRegistry.getField(valueOf)
+	if err != nil {
+		return ""
+	}
+
+	val, ok := fieldInfo.TagMap["comment"]
+	if ok {
+		return val
+	} else {
+		return ""
+	}
 }
@@ -1,14 +1,13 @@
 package builder
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	"github.com/tangpanqing/aorm/base"
-	"github.com/tangpanqing/aorm/driver"
 	"reflect"
-	"strconv"
 	"strings"
+	"time"
 )
 
 const Desc = "DESC"
@@ -31,10 +30,18 @@ const NotBetween = "NOT BETWEEN"
 const Raw = "Raw"
 const FindInSet = "FindInSet"
 const RawEq = "RawEq"
+const Group = "Group"
+const IsNull = "IS NULL"
+const IsNotNull = "IS NOT NULL"
+
+const And = "AND"
+const Or = "OR"
 
 // Builder 查询记录所需要的条件
 type Builder struct {
-	Link base.Link
+	Link     base.Link
+	ctx      context.Context
+	registry *Registry
 
 	table      interface{}
 	tableAlias string
@@ -47,14 +54,21 @@ type Builder struct {
 	havingList    []WhereItem
 	orderList     []OrderItem
 	limitItem     LimitItem
+	unionList     []UnionItem
+	setList       []SetItem
+	withList      []WithItem
 
 	distinct        bool
 	isDebug         bool
 	isLockForUpdate bool
+	isForcePrimary  bool
 
 	//sql与参数
 	query string
 	args  []interface{}
+
+	//链式操作中产生的错误,执行时返回
+	err error
 }
 
 // Debug 链式操作-是否开启调试,打印sql
@@ -63,6 +77,34 @@ func (b *Builder) Debug(isDebug bool) *Builder {
 	return b
 }
 
+// WithContext 链式操作-设置上下文,用于超时控制与取消查询
+func (b *Builder) WithContext(ctx context.Context) *Builder {
+	b.ctx = ctx
+	return b
+}
+
+// Registry 链式操作-使用指定的模型注册表,默认使用 DefaultRegistry
+func (b *Builder) Registry(registry *Registry) *Builder {
+	b.registry = registry
+	return b
+}
+
+//getRegistry 获取模型注册表
+func (b *Builder) getRegistry() *Registry {
+	if b.registry == nil {
+		return DefaultRegistry
+	}
+	return b.registry
+}
+
+// Context 获取上下文,没有设置则使用 context.Background()
+func (b *Builder) Context() context.Context {
+	if b.ctx == nil {
+		return context.Background()
+	}
+	return b.ctx
+}
+
 // Distinct 过滤重复记录
 func (b *Builder) Distinct(distinct bool) *Builder {
 	b.distinct = distinct
@@ -80,6 +122,10 @@ func (b *Builder) Table(table interface{}, alias ...string) *Builder {
 
 // Insert 增加记录
 func (b *Builder) Insert(dest interface{}) (int64, error) {
+	if err := b.callHook(hookBeforeInsert, dest); err != nil {
+		return 0, err
+	}
+
 	typeOf := reflect.TypeOf(dest)
 	valueOf := reflect.ValueOf(dest)
 
@@ -92,50 +138,66 @@ func (b *Builder) Insert(dest interface{}) (int64, error) {
 	for i := 0; i < typeOf.Elem().NumField(); i++ {
 		key, tagMap := getFieldNameByStructField(typeOf.Elem().Field(i))
 
-		//如果是Postgres数据库，寻找主键
-		if b.Link.DriverName() == driver.Postgres {
-			if _, ok := tagMap["primary"]; ok {
-				primaryKey = key
-			}
+		//寻找主键,部分数据库需要通过主键获取最后插入的id
+		if _, ok := tagMap["primary"]; ok {
+			primaryKey = key
 		}
 
-		isNotNull := valueOf.Elem().Field(i).Field(0).Field(1).Bool()
-		if isNotNull {
-			val := valueOf.Elem().Field(i).Field(0).Field(0).Interface()
+		if !typeOf.Elem().Field(i).IsExported() {
+			continue
+		}
 
+		val, isNotNull := getWriteValueByReflect(valueOf.Elem().Field(i), tagMap)
+		if isNotNull {
 			keys = append(keys, key)
 			args = append(args, val)
 			place = append(place, "?")
 		}
 	}
 
-	query := "INSERT INTO " + b.getTableNameCommon(typeOf, valueOf) + " (" + strings.Join(keys, ",") + ") VALUES (" + strings.Join(place, ",") + ")"
+	tableName, err := b.getTableNameCommon(typeOf, valueOf)
+	if err != nil {
+		return 0, err
+	}
 
-	if b.Link.DriverName() == driver.Mssql {
-		return b.insertForMssqlOrPostgres(query+"; SELECT SCOPE_IDENTITY()", args...)
-	} else if b.Link.DriverName() == driver.Postgres {
-		return b.insertForMssqlOrPostgres(convertToPostgresSql(query)+" RETURNING "+primaryKey, args...)
+	query := "INSERT INTO " + tableName + " (" + strings.Join(b.quoteList(keys), ",") + ") VALUES (" + strings.Join(place, ",") + ")"
+
+	var id int64
+	if returningQuery, isReturning := b.dialect().InsertReturning(query, primaryKey); isReturning {
+		id, err = b.insertForReturning(returningQuery, args...)
 	} else {
-		return b.insertForCommon(query, args...)
+		id, err = b.insertForCommon(query, args...)
+	}
+	if err != nil {
+		return 0, err
 	}
-}
 
-//对于Mssql,Postgres类型数据库，为了获取最后插入的id，需要改写入为查询
-func (b *Builder) insertForMssqlOrPostgres(query string, args ...any) (int64, error) {
-	if b.isDebug {
-		fmt.Println(query)
-		fmt.Println(args...)
+	if err = b.callHook(hookAfterInsert, dest); err != nil {
+		return id, err
 	}
 
-	rows, err := b.Link.Query(query, args...)
+	return id, nil
+}
+
+//对于Mssql,Postgres等类型数据库，为了获取最后插入的id，需要改写入为查询
+func (b *Builder) insertForReturning(query string, args ...any) (int64, error) {
+	query, args, err := b.convertPlaceholder(query, args)
 	if err != nil {
 		return 0, err
 	}
+
+	start := time.Now()
+	rows, err := b.Link.QueryContext(b.Context(), query, args...)
+	if err != nil {
+		b.logQuery(query, args, start, 0, err)
+		return 0, b.wrapError(err)
+	}
 	defer rows.Close()
 	var lastInsertId1 int64
 	for rows.Next() {
 		rows.Scan(&lastInsertId1)
 	}
+	b.logQuery(query, args, start, 1, nil)
 	return lastInsertId1, nil
 }
 
@@ -156,29 +218,70 @@ func (b *Builder) insertForCommon(query string, args ...any) (int64, error) {
 
 // InsertBatch 批量增加记录
 func (b *Builder) InsertBatch(values interface{}) (int64, error) {
-	var keys []string
-	var args []any
-	var place []string
-
 	valueOf := reflect.ValueOf(values).Elem()
 
 	if valueOf.Len() == 0 {
-		return 0, errors.New("the data list for insert batch not found")
+		return 0, ErrEmptyBatch
 	}
 	typeOf := reflect.TypeOf(values).Elem().Elem()
 
+	if err := b.callHookForSlice(hookBeforeInsert, valueOf, 0); err != nil {
+		return 0, err
+	}
+
+	var rows []reflect.Value
 	for j := 0; j < valueOf.Len(); j++ {
+		rows = append(rows, valueOf.Index(j))
+	}
+	if !isSameColumnsByReflect(typeOf, rows) {
+		return 0, ErrMixedColumns
+	}
+	keys, args, place := getInsertValuesByReflect(typeOf, rows)
+
+	tableName, err := b.getTableNameCommon(typeOf, valueOf.Index(0))
+	if err != nil {
+		return 0, err
+	}
+
+	query := "INSERT INTO " + tableName + " (" + strings.Join(b.quoteList(keys), ",") + ") VALUES " + strings.Join(place, ",")
+
+	res, err := b.RawSql(query, args...).Exec()
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if err = b.callHookForSlice(hookAfterInsert, valueOf, 0); err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+//getInsertValuesByReflect 获取多条记录写入的字段名,参数和占位符,字段名以第一条记录为准
+func getInsertValuesByReflect(typeOf reflect.Type, rows []reflect.Value) ([]string, []any, []string) {
+	var keys []string
+	var args []any
+	var place []string
+	for j := 0; j < len(rows); j++ {
 		var placeItem []string
 
-		for i := 0; i < valueOf.Index(j).Elem().NumField(); i++ {
-			isNotNull := valueOf.Index(j).Elem().Field(i).Field(0).Field(1).Bool()
+		for i := 0; i < rows[j].Elem().NumField(); i++ {
+			if !typeOf.Elem().Field(i).IsExported() {
+				continue
+			}
+
+			key, tagMap := getFieldNameByStructField(typeOf.Elem().Field(i))
+			val, isNotNull := getWriteValueByReflect(rows[j].Elem().Field(i), tagMap)
 			if isNotNull {
 				if j == 0 {
-					key, _ := getFieldNameByStructField(typeOf.Elem().Field(i))
 					keys = append(keys, key)
 				}
 
-				val := valueOf.Index(j).Elem().Field(i).Field(0).Field(0).Interface()
 				args = append(args, val)
 				placeItem = append(placeItem, "?")
 			}
@@ -187,32 +290,41 @@ func (b *Builder) InsertBatch(values interface{}) (int64, error) {
 		place = append(place, "("+strings.Join(placeItem, ",")+")")
 	}
 
-	query := "INSERT INTO " + b.getTableNameCommon(typeOf, valueOf.Index(0)) + " (" + strings.Join(keys, ",") + ") VALUES " + strings.Join(place, ",")
-
-	if b.Link.DriverName() == driver.Postgres {
-		query = convertToPostgresSql(query)
-	}
+	return keys, args, place
+}
 
-	res, err := b.RawSql(query, args...).Exec()
-	if err != nil {
-		return 0, err
-	}
+//isSameColumnsByReflect 批量写入时,每条记录的非空字段需要与第一条一致,字段列表按第一条生成
+func isSameColumnsByReflect(typeOf reflect.Type, rows []reflect.Value) bool {
+	for i := 0; i < typeOf.Elem().NumField(); i++ {
+		if !typeOf.Elem().Field(i).IsExported() {
+			continue
+		}
 
-	count, err := res.RowsAffected()
-	if err != nil {
-		return 0, err
+		_, tagMap := getFieldNameByStructField(typeOf.Elem().Field(i))
+		_, isNotNull := getWriteValueByReflect(rows[0].Elem().Field(i), tagMap)
+		for j := 1; j < len(rows); j++ {
+			if _, isNotNullRow := getWriteValueByReflect(rows[j].Elem().Field(i), tagMap); isNotNullRow != isNotNull {
+				return false
+			}
+		}
 	}
 
-	return count, nil
+	return true
 }
 
 // GetMany 查询记录(新)
 func (b *Builder) GetMany(values interface{}) error {
-	stmt, rows, errRows := b.GetRows()
+	_, err := b.getMany(values, nil)
+	return err
+}
+
+//getMany 查询记录,extraScans 中的列读取到对应的地址而不是结构体,返回读取的记录数
+func (b *Builder) getMany(values interface{}, extraScans map[string]interface{}) (int, error) {
+	_, rows, release, errRows := b.getRows(true)
 	if errRows != nil {
-		return errRows
+		return 0, errRows
 	}
-	defer stmt.Close()
+	defer release()
 	defer rows.Close()
 
 	destSlice := reflect.Indirect(reflect.ValueOf(values))
@@ -222,35 +334,41 @@ func (b *Builder) GetMany(values interface{}) error {
 	//从数据库中读出来的字段名字
 	columnNameList, errColumns := rows.Columns()
 	if errColumns != nil {
-		return errColumns
+		return 0, errColumns
 	}
 
 	//从结构体反射出来的属性名
 	fieldNameMap := getFieldMapByReflect(destType)
 
+	start := destSlice.Len()
 	for rows.Next() {
 		scans := getScansAddr(columnNameList, fieldNameMap, destValue)
+		for k := 0; k < len(columnNameList); k++ {
+			if addr, ok := extraScans[columnNameList[k]]; ok {
+				scans[k] = addr
+			}
+		}
 
 		errScan := rows.Scan(scans...)
 		if errScan != nil {
-			return errScan
+			return 0, errScan
 		}
 
 		destSlice.Set(reflect.Append(destSlice, destValue))
 	}
 
-	return nil
+	return destSlice.Len() - start, b.callHookForSlice(hookAfterFind, destSlice, start)
 }
 
 // GetOne 查询某一条记录
 func (b *Builder) GetOne(obj interface{}) error {
 	b.Limit(0, 1)
 
-	stmt, rows, errRows := b.GetRows()
+	_, rows, release, errRows := b.getRows(true)
 	if errRows != nil {
 		return errRows
 	}
-	defer stmt.Close()
+	defer release()
 	defer rows.Close()
 
 	if rows.Next() {
@@ -272,26 +390,41 @@ func (b *Builder) GetOne(obj interface{}) error {
 			return err
 		}
 
-		return nil
+		return b.callHook(hookAfterFind, obj)
 	} else {
-		return errors.New("NOT FOUND")
+		return ErrNotFound
 	}
 }
 
 // Update 更新记录
 func (b *Builder) Update(dest interface{}) (int64, error) {
+	if err := b.callHook(hookBeforeUpdate, dest); err != nil {
+		return 0, err
+	}
+
 	typeOf := reflect.TypeOf(dest)
 	valueOf := reflect.ValueOf(dest)
 
-	var args []any
-	setStr, args := b.handleSet(typeOf, valueOf, args)
-	whereStr, args, err := b.handleWhere(args, false)
+	tableName, err := b.getTableNameCommon(typeOf, valueOf)
 	if err != nil {
 		return 0, err
 	}
-	query := "UPDATE " + b.getTableNameCommon(typeOf, valueOf) + setStr + whereStr
 
-	return b.execAffected(query, args...)
+	//如果没有设置表名
+	if b.table == nil {
+		b.table = tableName
+	}
+
+	count, err := b.updateCommon(tableName, getSetListByReflect(typeOf, valueOf))
+	if err != nil {
+		return 0, err
+	}
+
+	if err = b.callHook(hookAfterUpdate, dest); err != nil {
+		return count, err
+	}
+
+	return count, nil
 }
 
 // Delete 删除记录
@@ -299,28 +432,45 @@ func (b *Builder) Delete(destList ...interface{}) (int64, error) {
 	tableName := ""
 
 	if len(destList) > 0 {
+		if err := b.callHook(hookBeforeDelete, destList[0]); err != nil {
+			return 0, err
+		}
+
 		b.Where(destList[0])
 
 		typeOf := reflect.TypeOf(destList[0])
 		valueOf := reflect.ValueOf(destList[0])
-		tableName = b.getTableNameCommon(typeOf, valueOf)
+		name, err := b.getTableNameCommon(typeOf, valueOf)
+		if err != nil {
+			return 0, err
+		}
+		tableName = name
 	}
 
 	if tableName == "" {
 		if b.table == nil {
-			return 0, errors.New("表名不能为空")
+			return 0, ErrMissingTable
+		}
+
+		name, err := b.getTableName(b.table)
+		if err != nil {
+			return 0, err
 		}
-		tableName = getTableNameByTable(b.table)
+		tableName = name
 	}
 
-	var args []any
-	whereStr, args, err := b.handleWhere(args, false)
+	count, err := b.deleteCommon(tableName)
 	if err != nil {
 		return 0, err
 	}
-	query := "DELETE FROM " + tableName + whereStr
 
-	return b.execAffected(query, args...)
+	if len(destList) > 0 {
+		if err = b.callHook(hookAfterDelete, destList[0]); err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
 }
 
 // GroupBy 链式操作,以某字段进行分组
@@ -356,115 +506,185 @@ func (b *Builder) LockForUpdate(isLockForUpdate bool) *Builder {
 	return b
 }
 
+// ForcePrimary 读写分离时,强制查询主库,用于写入后立即读取
+func (b *Builder) ForcePrimary() *Builder {
+	b.isForcePrimary = true
+	return b
+}
+
 // Truncate 清空记录
 func (b *Builder) Truncate() (int64, error) {
 	if b.table == nil {
-		return 0, errors.New("表名不能为空")
+		return 0, ErrMissingTable
 	}
 
-	query := ""
-	if b.Link.DriverName() == driver.Sqlite3 {
-		query = "DELETE FROM " + getTableNameByTable(b.table)
-	} else {
-		query = "TRUNCATE TABLE " + getTableNameByTable(b.table)
+	tableName, err := b.getTableName(b.table)
+	if err != nil {
+		return 0, err
 	}
 
-	return b.execAffected(query)
+	return b.execAffected(b.dialect().Truncate(tableName))
 }
 
-// RawSql 执行原始的sql语句
+// RawSql 执行原始的sql语句,参数只有一个 Named 或 NamedStruct 时,按 :name,@name 绑定命名参数,?? 表示字面的 ?
 func (b *Builder) RawSql(query string, args ...interface{}) *Builder {
 	b.query = query
 	b.args = args
 	return b
 }
 
-// GetRows 获取行操作
+// GetRows 获取行操作,不使用语句缓存,返回的 stmt 与 rows 需要调用方关闭
 func (b *Builder) GetRows() (*sql.Stmt, *sql.Rows, error) {
-	query, args, err := b.GetSqlAndParams()
-	if err != nil {
-		return nil, nil, err
+	smt, rows, _, err := b.getRows(false)
+	return smt, rows, err
+}
+
+//getRows 获取行操作,useCache 为 true 时使用语句缓存,使用完需要关闭 rows 并调用 release
+func (b *Builder) getRows(useCache bool) (*sql.Stmt, *sql.Rows, func(), error) {
+	if err := b.checkFeatures(); err != nil {
+		return nil, nil, nil, err
 	}
 
-	if b.Link.DriverName() == driver.Postgres {
-		query = convertToPostgresSql(query)
+	query, args, err := b.GetSqlAndParams()
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	if b.isDebug {
-		fmt.Println(query)
-		fmt.Println(args...)
+	query, args, err = b.convertPlaceholder(query, args)
+	if err != nil {
+		return nil, nil, nil, err
 	}
 
-	smt, errSmt := b.Link.Prepare(query)
+	start := time.Now()
+	smt, release, errSmt := b.prepare(b.getReadLink(), query, useCache)
 	if errSmt != nil {
-		return nil, nil, errSmt
+		b.logQuery(query, args, start, -1, errSmt)
+		return nil, nil, nil, b.wrapError(errSmt)
 	}
 
-	rows, errRows := smt.Query(args...)
+	rows, errRows := smt.QueryContext(b.Context(), args...)
 	if errRows != nil {
-		return nil, nil, errRows
+		release()
+		b.logQuery(query, args, start, -1, errRows)
+		return nil, nil, nil, b.wrapError(errRows)
 	}
 
-	return smt, rows, nil
+	b.logQuery(query, args, start, -1, nil)
+	return smt, rows, release, nil
 }
 
-// Exec 通用执行-新增,更新,删除
-func (b *Builder) Exec() (sql.Result, error) {
-	if b.Link.DriverName() == driver.Postgres {
-		b.query = convertToPostgresSql(b.query)
+//prepare 预处理语句,连接支持语句缓存时从缓存获取,使用完需要调用 release
+func (b *Builder) prepare(link base.Link, query string, useCache bool) (*sql.Stmt, func(), error) {
+	if cacheLink, ok := link.(base.StmtCacheLink); ok && useCache {
+		return cacheLink.PrepareCached(b.Context(), query)
 	}
 
-	if b.isDebug {
-		fmt.Println(b.query)
-		fmt.Println(b.args...)
+	smt, err := link.PrepareContext(b.Context(), query)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	smt, err1 := b.Link.Prepare(b.query)
+	return smt, func() { smt.Close() }, nil
+}
+
+// Exec 通用执行-新增,更新,删除
+func (b *Builder) Exec() (sql.Result, error) {
+	query, args, err := b.convertPlaceholder(b.query, b.args)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	smt, release, err1 := b.prepare(b.Link, query, true)
 	if err1 != nil {
-		return nil, err1
+		b.logQuery(query, args, start, 0, err1)
+		return nil, b.wrapError(err1)
 	}
-	defer smt.Close()
+	defer release()
 
-	res, err2 := smt.Exec(b.args...)
+	res, err2 := smt.ExecContext(b.Context(), args...)
 	if err2 != nil {
-		return nil, err2
+		b.logQuery(query, args, start, 0, err2)
+		return nil, b.wrapError(err2)
 	}
 
+	rowsAffected, errAffected := res.RowsAffected()
+	if errAffected != nil {
+		rowsAffected = -1
+	}
+	b.logQuery(query, args, start, rowsAffected, nil)
+
 	//b.clear()
 	return res, nil
 }
 
+//getReadLink 获取查询使用的连接,读写分离时,生成的查询使用从库,原始sql与加锁查询使用主库
+func (b *Builder) getReadLink() base.Link {
+	if b.isForcePrimary || b.isLockForUpdate || b.query != "" {
+		return b.Link
+	}
+
+	if replicaLink, ok := b.Link.(base.ReplicaLink); ok {
+		return replicaLink.Replica()
+	}
+
+	return b.Link
+}
+
+// Logger 获取日志
+func (b *Builder) Logger() base.Logger {
+	return b.Link.GetLogger()
+}
+
+//logQuery 记录执行过的sql
+func (b *Builder) logQuery(query string, args []any, start time.Time, rowsAffected int64, err error) {
+	b.Logger().LogQuery(b.Context(), base.QueryLog{
+		Driver:       b.Link.DriverName(),
+		Sql:          query,
+		Args:         args,
+		Duration:     time.Since(start),
+		RowsAffected: rowsAffected,
+		Err:          err,
+		Debug:        b.isDebug,
+	})
+}
+
 //拼接SQL,查询与筛选通用操作
-func (b *Builder) whereAndHaving(where []WhereItem, args []any, isFromHaving bool, needPrefix bool) ([]string, []any, error) {
+func (b *Builder) whereAndHaving(where []WhereItem, args []any, isFromHaving bool, needPrefix bool) (string, []any, error) {
 	var whereList []string
+	var logicList []string
 	for i := 0; i < len(where); i++ {
-		valueOfField := reflect.ValueOf(where[i].Field)
+		count := len(whereList)
 
-		allFieldName := ""
-		if needPrefix {
-			prefix := getPrefixByField(valueOfField, where[i].Prefix...)
-			if prefix != "" {
-				allFieldName += prefix + "."
+		//条件组,递归拼接后加上括号
+		if where[i].Opt == Group {
+			groupList, _ := where[i].Val.([]WhereItem)
+			if len(groupList) == 0 {
+				continue
 			}
-		}
 
-		//如果是mssql或者Postgres,并且来自having的话，需要特殊处理
-		if (b.Link.DriverName() == driver.Mssql || b.Link.DriverName() == driver.Postgres) && isFromHaving {
-			fieldNameCurrent := getFieldNameByReflectValue(valueOfField)
-			for m := 0; m < len(b.selectList); m++ {
-				if fieldNameCurrent == getFieldNameByField(b.selectList[m].FieldNew) {
-					allFieldName += handleSelectWith(b.selectList[m])
-				}
+			groupStr, groupArgs, err := b.whereAndHaving(groupList, args, isFromHaving, needPrefix)
+			if err != nil {
+				return "", args, err
 			}
-		} else {
-			allFieldName += getFieldNameByReflectValue(valueOfField)
+
+			args = groupArgs
+			whereList = append(whereList, "("+groupStr+")")
+			logicList = append(logicList, where[i].Logic)
+			continue
 		}
 
-		if "**builder.Builder" == reflect.TypeOf(where[i].Val).String() {
-			subBuilder := *(**Builder)(reflect.ValueOf(where[i].Val).UnsafePointer())
-			subSql, subParams, err := subBuilder.GetSqlAndParams()
+		var allFieldName string
+		var err error
+		allFieldName, args, err = b.getWhereFieldSql(where[i], args, isFromHaving, needPrefix)
+		if err != nil {
+			return "", args, err
+		}
+
+		if subBuilder, ok := where[i].Val.(**Builder); ok {
+			subSql, subParams, err := (*subBuilder).GetSqlAndParams()
 			if err != nil {
-				return whereList, args, err
+				return "", args, err
 			}
 
 			if where[i].Opt != Raw {
@@ -472,21 +692,34 @@ func (b *Builder) whereAndHaving(where []WhereItem, args []any, isFromHaving boo
 				args = append(args, subParams...)
 			}
 		} else {
-			if where[i].Opt == Eq || where[i].Opt == Ne || where[i].Opt == Gt || where[i].Opt == Ge || where[i].Opt == Lt || where[i].Opt == Le {
-				if b.Link.DriverName() == driver.Sqlite3 {
+			if expr, ok := where[i].Val.(Expr); ok && (where[i].Opt == Eq || where[i].Opt == Ne || where[i].Opt == Gt || where[i].Opt == Ge || where[i].Opt == Lt || where[i].Opt == Le) {
+				exprSql, exprArgs, err := expr.toSql(b)
+				if err != nil {
+					return "", args, err
+				}
+
+				whereList = append(whereList, allFieldName+" "+where[i].Opt+" "+exprSql)
+				args = append(args, exprArgs...)
+			} else if where[i].Opt == Eq || where[i].Opt == Ne || where[i].Opt == Gt || where[i].Opt == Ge || where[i].Opt == Lt || where[i].Opt == Le {
+				if _, isExpr := where[i].Field.(Expr); isExpr {
 					whereList = append(whereList, allFieldName+" "+where[i].Opt+" "+"?")
 				} else {
 					switch where[i].Val.(type) {
 					case float32:
-						whereList = append(whereList, b.getConcatForFloat(allFieldName, "''")+" "+where[i].Opt+" "+"?")
+						whereList = append(whereList, b.queryDialect().CompareFloat(allFieldName)+" "+where[i].Opt+" "+"?")
 					case float64:
-						whereList = append(whereList, b.getConcatForFloat(allFieldName, "''")+" "+where[i].Opt+" "+"?")
+						whereList = append(whereList, b.queryDialect().CompareFloat(allFieldName)+" "+where[i].Opt+" "+"?")
 					default:
 						whereList = append(whereList, allFieldName+" "+where[i].Opt+" "+"?")
 					}
 				}
 
-				args = append(args, fmt.Sprintf("%v", where[i].Val))
+				//浮点数按字符串比较,以免精度问题,其他值保持原类型绑定,例如 bool,time.Time
+				if _, isExpr := where[i].Field.(Expr); isExpr {
+					args = append(args, where[i].Val)
+				} else {
+					args = append(args, getCompareValue(where[i].Val))
+				}
 			}
 
 			if where[i].Opt == Between || where[i].Opt == NotBetween {
@@ -499,13 +732,11 @@ func (b *Builder) whereAndHaving(where []WhereItem, args []any, isFromHaving boo
 				values := toAnyArr(where[i].Val)
 				var valueStr []string
 				for j := 0; j < len(values); j++ {
-					str := fmt.Sprintf("%v", values[j])
-
-					if "%" != str {
-						args = append(args, str)
-						valueStr = append(valueStr, "?")
+					if str, ok := values[j].(string); ok && str == "%" {
+						valueStr = append(valueStr, "'%'")
 					} else {
-						valueStr = append(valueStr, "'"+str+"'")
+						args = append(args, values[j])
+						valueStr = append(valueStr, "?")
 					}
 				}
 
@@ -524,54 +755,159 @@ func (b *Builder) whereAndHaving(where []WhereItem, args []any, isFromHaving boo
 			}
 
 			if where[i].Opt == FindInSet {
-				whereList = append(whereList, "FIND_IN_SET(?,"+getPrefixByField(reflect.ValueOf(where[i].Field), where[i].Prefix...)+"."+getFieldNameByField(where[i].Field)+")")
+				fieldSql, fieldArgs, err := b.getFieldSql(where[i].Field, where[i].Prefix...)
+				if err != nil {
+					return "", args, err
+				}
+
+				whereList = append(whereList, "FIND_IN_SET(?,"+fieldSql+")")
 				args = append(args, where[i].Val)
+				args = append(args, fieldArgs...)
 			}
 
 			if where[i].Opt == Raw {
-				whereList = append(whereList, allFieldName+" "+fmt.Sprintf("%v", where[i].Val))
+				if raw, ok := where[i].Val.(rawSql); ok {
+					rawStr, rawArgs, err := b.bindNamed(raw.query, raw.args)
+					if err != nil {
+						return "", args, err
+					}
+					whereList = append(whereList, allFieldName+" "+rawStr)
+					args = append(args, rawArgs...)
+				} else {
+					whereList = append(whereList, allFieldName+" "+fmt.Sprintf("%v", where[i].Val))
+				}
+			}
+
+			if where[i].Opt == IsNull || where[i].Opt == IsNotNull {
+				whereList = append(whereList, allFieldName+" "+where[i].Opt)
 			}
 
 			if where[i].Opt == RawEq {
-				whereList = append(whereList, allFieldName+Eq+getPrefixByField(reflect.ValueOf(where[i].Val))+"."+getFieldNameByField(where[i].Val))
+				prefix, err := b.getPrefix(where[i].Val)
+				if err != nil {
+					return "", args, err
+				}
+
+				fieldName, err := b.getFieldName(where[i].Val)
+				if err != nil {
+					return "", args, err
+				}
+
+				whereList = append(whereList, allFieldName+Eq+prefix+"."+fieldName)
 			}
 		}
+
+		if len(whereList) > count {
+			logicList = append(logicList, where[i].Logic)
+		}
 	}
-	return whereList, args, nil
+
+	return joinWhereList(whereList, logicList), args, nil
 }
 
-func (b *Builder) getConcatForFloat(vars ...string) string {
-	if b.Link.DriverName() == driver.Sqlite3 {
-		return strings.Join(vars, "||")
-	} else if b.Link.DriverName() == driver.Postgres {
-		return vars[0]
+//getWhereFieldSql 获取条件中的字段,表达式的参数放在条件值的参数之前
+func (b *Builder) getWhereFieldSql(whereItem WhereItem, args []any, isFromHaving bool, needPrefix bool) (string, []any, error) {
+	if expr, ok := whereItem.Field.(Expr); ok {
+		exprSql, exprArgs, err := expr.toSql(b)
+		if err != nil {
+			return "", args, err
+		}
+		return exprSql, append(args, exprArgs...), nil
+	}
+
+	allFieldName := ""
+	if needPrefix {
+		prefix, err := b.getPrefix(whereItem.Field, whereItem.Prefix...)
+		if err != nil {
+			return "", args, err
+		}
+		if prefix != "" {
+			allFieldName += prefix + "."
+		}
+	}
+
+	fieldNameCurrent, err := b.getRegistry().getFieldNameByField(whereItem.Field)
+	if err != nil {
+		return "", args, err
+	}
+
+	//来自having,并且数据库不能使用别名时,展开为查询字段的表达式,例如mssql或者Postgres
+	if isFromHaving && !b.queryDialect().HavingAlias() {
+		for m := 0; m < len(b.selectList); m++ {
+			fieldNameNew, err := b.getRegistry().getFieldNameByField(b.selectList[m].FieldNew)
+			if err != nil {
+				return "", args, err
+			}
+
+			if fieldNameCurrent == fieldNameNew {
+				selectStr, selectArgs, err := b.handleSelectWith(b.selectList[m])
+				if err != nil {
+					return "", args, err
+				}
+				allFieldName += selectStr
+				args = append(args, selectArgs...)
+			}
+		}
 	} else {
-		return "CONCAT(" + strings.Join(vars, ",") + ")"
+		allFieldName += b.quote(fieldNameCurrent)
 	}
+
+	return allFieldName, args, nil
 }
 
-func (b *Builder) getConcatForLike(vars ...string) string {
-	if b.Link.DriverName() == driver.Sqlite3 || b.Link.DriverName() == driver.Postgres {
-		return strings.Join(vars, "||")
-	} else {
-		return "CONCAT(" + strings.Join(vars, ",") + ")"
+//joinWhereList 按照每个条件的逻辑关系拼接,默认为 AND
+func joinWhereList(whereList []string, logicList []string) string {
+	str := ""
+	for i := 0; i < len(whereList); i++ {
+		if i > 0 {
+			if logicList[i] == Or {
+				str += " " + Or + " "
+			} else {
+				str += " " + And + " "
+			}
+		}
+		str += whereList[i]
 	}
+	return str
+}
+
+//getCompareValue 比较条件的参数,浮点数转为字符串,与 CompareFloat 处理后的字段比较,其他值原样绑定
+func getCompareValue(val interface{}) interface{} {
+	switch val.(type) {
+	case float32, float64:
+		return fmt.Sprintf("%v", val)
+	}
+	return val
+}
+
+func (b *Builder) getConcatForLike(vars ...string) string {
+	return b.dialect().Concat(vars...)
 }
 
-func (b *Builder) getTableNameCommon(typeOf reflect.Type, valueOf reflect.Value) string {
+func (b *Builder) getTableNameCommon(typeOf reflect.Type, valueOf reflect.Value) (string, error) {
 	if b.table != nil {
-		return getTableNameByTable(b.table)
+		return b.getTableName(b.table)
 	}
 
-	return getTableNameByReflect(typeOf, valueOf)
+	return b.quote(getTableNameByReflect(typeOf, valueOf)), nil
 }
 
 func (b *Builder) GetSqlAndParams() (string, []interface{}, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+
+	//原始sql作为子查询时,命名参数需要先转成 ? 占位符
 	if b.query != "" {
-		return b.query, b.args, nil
+		return b.bindNamed(b.query, b.args)
 	}
 
 	var args []interface{}
+	withStr, args, err := b.handleWith(args)
+	if err != nil {
+		return "", args, err
+	}
+
 	selectStr, args, err := b.handleSelect(args)
 	if err != nil {
 		return "", args, err
@@ -581,19 +917,40 @@ func (b *Builder) GetSqlAndParams() (string, []interface{}, error) {
 	if err != nil {
 		return "", args, err
 	}
-	joinStr, args := b.handleJoin(args)
+
+	joinStr, args, err := b.handleJoin(args)
+	if err != nil {
+		return "", args, err
+	}
+
 	whereStr, args, err := b.handleWhere(args, true)
 	if err != nil {
 		return "", args, err
 	}
 
-	groupStr, args := b.handleGroup(args)
+	groupStr, args, err := b.handleGroup(args)
+	if err != nil {
+		return "", args, err
+	}
+
 	havingStr, args, err := b.handleHaving(args)
 	if err != nil {
 		return "", args, err
 	}
 
-	orderStr, args := b.handleOrder(args)
+	if len(b.unionList) > 0 {
+		unionStr, args, err := b.handleUnion(selectStr+tableStr+joinStr+whereStr+groupStr+havingStr, args)
+		if err != nil {
+			return "", args, err
+		}
+		return withStr + unionStr, args, nil
+	}
+
+	orderStr, args, err := b.handleOrder(args)
+	if err != nil {
+		return "", args, err
+	}
+
 	limitStr, args := b.handleLimit(args)
 	lockStr := b.handleLockForUpdate()
 
@@ -602,6 +959,7 @@ func (b *Builder) GetSqlAndParams() (string, []interface{}, error) {
 	//return query, args, nil
 
 	var bd strings.Builder
+	bd.WriteString(withStr)
 	bd.WriteString(selectStr)
 	bd.WriteString(tableStr)
 	bd.WriteString(joinStr)
@@ -617,8 +975,8 @@ func (b *Builder) GetSqlAndParams() (string, []interface{}, error) {
 
 // execAffected 通用执行-更新,删除
 func (b *Builder) execAffected(query string, args ...interface{}) (int64, error) {
-	if b.Link.DriverName() == driver.Postgres {
-		query = convertToPostgresSql(query)
+	if err := b.checkFeatures(); err != nil {
+		return 0, err
 	}
 
 	res, err := b.RawSql(query, args...).Exec()
@@ -648,17 +1006,3 @@ func getTagMap(fieldTag string) map[string]string {
 	}
 	return fieldMap
 }
-
-//对于Postgres数据库，不支持?占位符，支持$1,$2类型，需要做转换
-func convertToPostgresSql(query string) string {
-	t := 1
-	for {
-		if strings.Index(query, "?") == -1 {
-			break
-		}
-		query = strings.Replace(query, "?", "$"+strconv.Itoa(t), 1)
-		t += 1
-	}
-
-	return query
-}
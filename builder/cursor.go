@@ -0,0 +1,298 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/tangpanqing/aorm/driver"
+	"github.com/tangpanqing/aorm/utils"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// CursorPagination 游标分页结果,游标为空表示没有下一页或上一页
+type CursorPagination struct {
+	Next string
+	Prev string
+	Size int
+}
+
+//cursorToken 游标内容,Prev 为 true 时向前翻页
+type cursorToken struct {
+	Prev bool        `json:"p,omitempty"`
+	Keys []cursorKey `json:"k"`
+}
+
+//cursorKey 游标中的排序字段值,时间类型需要标记,解码时还原
+type cursorKey struct {
+	Type string      `json:"t,omitempty"`
+	Val  interface{} `json:"v"`
+}
+
+// CursorPaginate 游标分页,按排序字段的值定位,不使用偏移量
+// orderFields 追加到 OrderBy 设置的排序之后,排序字段需要能唯一确定一条记录,例如最后加上主键,并且不能为空值
+// after 为上一次返回的 Next 或 Prev,为空时查询第一页
+func (b *Builder) CursorPaginate(orderFields []OrderItem, after string, pageSize int, values interface{}) (CursorPagination, error) {
+	if pageSize <= 0 {
+		return CursorPagination{}, ErrInvalidPageSize
+	}
+
+	orderList := append(append([]OrderItem(nil), b.orderList...), orderFields...)
+	if len(orderList) == 0 {
+		return CursorPagination{}, ErrMissingOrder
+	}
+
+	//表达式无法从结果中读出游标的值
+	for i := 0; i < len(orderList); i++ {
+		if _, isExpr := orderList[i].Field.(Expr); isExpr {
+			return CursorPagination{}, fmt.Errorf("%w: cursor paginate order by expression", ErrNotSupported)
+		}
+	}
+
+	var token cursorToken
+	if after != "" {
+		var err error
+		token, err = decodeCursor(after, len(orderList))
+		if err != nil {
+			return CursorPagination{}, err
+		}
+	}
+
+	//向前翻页时反转排序,查询后再反转结果
+	b.orderList = nil
+	for i := 0; i < len(orderList); i++ {
+		orderType := orderList[i].OrderType
+		if token.Prev {
+			orderType = reverseOrderType(orderType)
+		}
+		b.orderList = append(b.orderList, OrderItem{orderList[i].Prefix, orderList[i].Field, orderType})
+	}
+
+	if after != "" {
+		condition, args, err := b.getCursorCondition(token.Keys)
+		if err != nil {
+			return CursorPagination{}, err
+		}
+
+		//原有条件放入条件组,避免其中的 OR 与游标条件混在一起
+		whereList := b.whereList
+		b.whereList = nil
+		if len(whereList) > 0 {
+			b.whereList = append(b.whereList, WhereItem{Opt: Group, Val: whereList, Logic: And})
+		}
+		b.WhereRaw(condition, args...)
+	}
+
+	//多查询一条,判断是否还有下一页
+	destSlice := reflect.Indirect(reflect.ValueOf(values))
+	rowList := reflect.New(destSlice.Type())
+	if err := b.Limit(0, pageSize+1).GetMany(rowList.Interface()); err != nil {
+		return CursorPagination{}, err
+	}
+
+	rows := rowList.Elem()
+	hasMore := rows.Len() > pageSize
+	if hasMore {
+		rows = rows.Slice(0, pageSize)
+	}
+	if token.Prev {
+		swap := reflect.Swapper(rows.Interface())
+		for i, j := 0, rows.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+	destSlice.Set(reflect.AppendSlice(destSlice, rows))
+
+	pagination := CursorPagination{Size: pageSize}
+	if rows.Len() == 0 {
+		//没有记录时,从当前游标的位置往回翻
+		if after != "" {
+			var err error
+			if token.Prev {
+				pagination.Next, err = encodeCursor(false, token.Keys)
+			} else {
+				pagination.Prev, err = encodeCursor(true, token.Keys)
+			}
+			if err != nil {
+				return CursorPagination{}, err
+			}
+		}
+		return pagination, nil
+	}
+
+	firstKeys, err := b.getCursorKeys(orderList, rows.Index(0))
+	if err != nil {
+		return CursorPagination{}, err
+	}
+	lastKeys, err := b.getCursorKeys(orderList, rows.Index(rows.Len()-1))
+	if err != nil {
+		return CursorPagination{}, err
+	}
+
+	if token.Prev || hasMore {
+		if pagination.Next, err = encodeCursor(false, lastKeys); err != nil {
+			return CursorPagination{}, err
+		}
+	}
+	if (token.Prev && hasMore) || (!token.Prev && after != "") {
+		if pagination.Prev, err = encodeCursor(true, firstKeys); err != nil {
+			return CursorPagination{}, err
+		}
+	}
+
+	return pagination, nil
+}
+
+//getCursorCondition 生成游标的条件,排序方向一致且数据库支持行值时使用 (a,b) > (?,?),否则展开为 a > ? OR (a = ? AND b > ?)
+func (b *Builder) getCursorCondition(keys []cursorKey) (string, []interface{}, error) {
+	var fieldList []string
+	var optList []string
+	var args []interface{}
+	for i := 0; i < len(b.orderList); i++ {
+		if len(b.orderList[i].Prefix) == 0 && b.isSelectAlias(b.orderList[i].Field) {
+			return "", nil, fmt.Errorf("%w: cursor paginate order by select alias", ErrNotSupported)
+		}
+
+		fieldSql, _, err := b.getFieldSql(b.orderList[i].Field, b.orderList[i].Prefix...)
+		if err != nil {
+			return "", nil, err
+		}
+
+		fieldList = append(fieldList, fieldSql)
+		if strings.ToUpper(b.orderList[i].OrderType) == Desc {
+			optList = append(optList, Lt)
+		} else {
+			optList = append(optList, Gt)
+		}
+	}
+
+	if len(fieldList) == 1 {
+		return fieldList[0] + " " + optList[0] + " ?", []interface{}{keys[0].Val}, nil
+	}
+
+	isSameOpt := true
+	for i := 1; i < len(optList); i++ {
+		if optList[i] != optList[0] {
+			isSameOpt = false
+		}
+	}
+
+	isRowValue, err := b.supports(driver.FeatureRowValue, false)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if isSameOpt && isRowValue {
+		var place []string
+		for i := 0; i < len(keys); i++ {
+			place = append(place, "?")
+			args = append(args, keys[i].Val)
+		}
+		return "(" + strings.Join(fieldList, ",") + ") " + optList[0] + " (" + strings.Join(place, ",") + ")", args, nil
+	}
+
+	var orList []string
+	for i := 0; i < len(fieldList); i++ {
+		var andList []string
+		for j := 0; j < i; j++ {
+			andList = append(andList, fieldList[j]+" = ?")
+			args = append(args, keys[j].Val)
+		}
+		andList = append(andList, fieldList[i]+" "+optList[i]+" ?")
+		args = append(args, keys[i].Val)
+		orList = append(orList, "("+strings.Join(andList, " AND ")+")")
+	}
+
+	return "(" + strings.Join(orList, " OR ") + ")", args, nil
+}
+
+//getCursorKeys 从记录中读取排序字段的值
+func (b *Builder) getCursorKeys(orderList []OrderItem, row reflect.Value) ([]cursorKey, error) {
+	row = reflect.Indirect(row)
+	fieldNameMap := getFieldMapByReflect(row.Type())
+
+	var keys []cursorKey
+	for i := 0; i < len(orderList); i++ {
+		columnName, err := b.getRegistry().getFieldNameByField(orderList[i].Field)
+		if err != nil {
+			return nil, err
+		}
+
+		index, ok := fieldNameMap[utils.CamelString(strings.ToLower(columnName))]
+		if !ok {
+			return nil, fmt.Errorf("%w: cursor field %s not found in result", ErrUnregisteredField, columnName)
+		}
+
+		fieldValue := row
+		for j := 0; j < len(index); j++ {
+			fieldValue = fieldValue.Field(index[j])
+		}
+
+		val, _ := getValueByReflect(fieldValue)
+		if t, isTime := val.(time.Time); isTime {
+			keys = append(keys, cursorKey{"time", t.Format(time.RFC3339Nano)})
+		} else {
+			keys = append(keys, cursorKey{"", val})
+		}
+	}
+
+	return keys, nil
+}
+
+//reverseOrderType 反转排序方向
+func reverseOrderType(orderType string) string {
+	if strings.ToUpper(orderType) == Desc {
+		return Asc
+	}
+	return Desc
+}
+
+//encodeCursor 编码游标,json 后 base64
+func encodeCursor(prev bool, keys []cursorKey) (string, error) {
+	data, err := json.Marshal(cursorToken{prev, keys})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+//decodeCursor 解码游标,数字优先还原为整数,时间还原为 time.Time
+func decodeCursor(cursor string, keyCount int) (cursorToken, error) {
+	var token cursorToken
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return token, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&token); err != nil {
+		return token, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	if len(token.Keys) != keyCount {
+		return token, fmt.Errorf("%w: expect %d keys, got %d", ErrInvalidCursor, keyCount, len(token.Keys))
+	}
+
+	for i := 0; i < len(token.Keys); i++ {
+		switch val := token.Keys[i].Val.(type) {
+		case json.Number:
+			if n, errInt := val.Int64(); errInt == nil {
+				token.Keys[i].Val = n
+			} else if f, errFloat := val.Float64(); errFloat == nil {
+				token.Keys[i].Val = f
+			}
+		case string:
+			if token.Keys[i].Type == "time" {
+				t, errTime := time.Parse(time.RFC3339Nano, val)
+				if errTime != nil {
+					return token, fmt.Errorf("%w: %v", ErrInvalidCursor, errTime)
+				}
+				token.Keys[i].Val = t
+			}
+		}
+	}
+
+	return token, nil
+}
@@ -0,0 +1,68 @@
+package builder
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2023, 5, 6, 7, 8, 9, 123456789, time.UTC)
+
+	tests := []struct {
+		prev bool
+		keys []cursorKey
+		want []cursorKey
+	}{
+		{false, []cursorKey{{"", 42}}, []cursorKey{{"", int64(42)}}},
+		{true, []cursorKey{{"", int64(-9007199254740993)}}, []cursorKey{{"", int64(-9007199254740993)}}},
+		{false, []cursorKey{{"", 1.5}, {"", "Alice"}}, []cursorKey{{"", 1.5}, {"", "Alice"}}},
+		{true, []cursorKey{{"time", created.Format(time.RFC3339Nano)}, {"", 7}}, []cursorKey{{"time", created}, {"", int64(7)}}},
+		{false, []cursorKey{{"", nil}, {"", true}}, []cursorKey{{"", nil}, {"", true}}},
+	}
+
+	for _, tt := range tests {
+		cursor, err := encodeCursor(tt.prev, tt.keys)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		token, err := decodeCursor(cursor, len(tt.keys))
+		if err != nil {
+			t.Fatalf("decodeCursor(%q) error = %v", cursor, err)
+		}
+		if token.Prev != tt.prev || !reflect.DeepEqual(token.Keys, tt.want) {
+			t.Fatalf("decodeCursor(%q) = %v %v, want %v %v", cursor, token.Prev, token.Keys, tt.prev, tt.want)
+		}
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	valid, err := encodeCursor(false, []cursorKey{{"", 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		cursor   string
+		keyCount int
+	}{
+		{"", 1},
+		{"!!!", 1},
+		{valid + "=", 1},
+		{valid[:len(valid)-2], 1},
+		{valid, 2},
+		{valid, 0},
+		{base64.RawURLEncoding.EncodeToString([]byte("not json")), 1},
+		{base64.RawURLEncoding.EncodeToString([]byte(`{"k":"x"}`)), 1},
+		{base64.RawURLEncoding.EncodeToString([]byte(`{"k":[{"t":"time","v":"yesterday"}]}`)), 1},
+	}
+
+	for _, tt := range tests {
+		if _, err = decodeCursor(tt.cursor, tt.keyCount); !errors.Is(err, ErrInvalidCursor) {
+			t.Fatalf("decodeCursor(%q, %d) error = %v, want %v", tt.cursor, tt.keyCount, err, ErrInvalidCursor)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package builder
+
+import (
+	"fmt"
+	"github.com/tangpanqing/aorm/driver"
+	"strings"
+)
+
+//deleteCommon 通用删除,支持排序,数量限制与关联删除
+func (b *Builder) deleteCommon(tableName string) (int64, error) {
+	var args []any
+	withStr, args, err := b.handleWith(args)
+	if err != nil {
+		return 0, err
+	}
+
+	var query string
+	if b.isLimitWrite() {
+		query, args, err = b.getDeleteSqlWithLimit(tableName, args)
+	} else if len(b.joinList) > 0 {
+		query, args, err = b.getDeleteSqlWithJoin(tableName, args)
+	} else {
+		var whereStr string
+		whereStr, args, err = b.handleWhere(args, false)
+		query = "DELETE FROM " + tableName + whereStr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return b.execAffected(withStr+query, args...)
+}
+
+//getDeleteSqlWithLimit 有排序或数量限制的删除
+func (b *Builder) getDeleteSqlWithLimit(tableName string, args []any) (string, []any, error) {
+	syntax := b.writeSyntax()
+	if syntax.Limit == driver.WriteLimit {
+		if len(b.joinList) > 0 {
+			return "", args, fmt.Errorf("%w: %s delete with join and limit", ErrNotSupported, b.Link.DriverName())
+		}
+
+		whereStr, args, err := b.handleWhere(args, false)
+		if err != nil {
+			return "", args, err
+		}
+
+		limitStr, args, err := b.getWriteLimit(args)
+		if err != nil {
+			return "", args, err
+		}
+
+		return "DELETE FROM " + tableName + whereStr + limitStr, args, nil
+	}
+
+	if syntax.Limit == driver.WriteTop {
+		if len(b.joinList) > 0 {
+			return "", args, fmt.Errorf("%w: %s delete with join and limit", ErrNotSupported, b.Link.DriverName())
+		}
+
+		//没有排序时使用 TOP,否则删除排序分页后的派生表
+		if len(b.orderList) == 0 {
+			topStr, args, err := b.getWriteTop(args)
+			if err != nil {
+				return "", args, err
+			}
+
+			whereStr, args, err := b.handleWhere(args, false)
+			if err != nil {
+				return "", args, err
+			}
+
+			return "DELETE" + topStr + " FROM " + tableName + whereStr, args, nil
+		}
+
+		alias := b.getWriteAlias(tableName)
+		subSql, args, err := b.getWriteSubQuery(tableName, args)
+		if err != nil {
+			return "", args, err
+		}
+
+		return "DELETE " + alias + " FROM (" + subSql + ") " + alias, args, nil
+	}
+
+	rowIdStr, args, err := b.getRowIdCondition(tableName, args)
+	if err != nil {
+		return "", args, err
+	}
+
+	return "DELETE FROM " + b.getUpdateTable(tableName) + " WHERE " + rowIdStr, args, nil
+}
+
+//getDeleteSqlWithJoin 关联删除,Mysql,Mssql DELETE t FROM t JOIN o,Postgres DELETE FROM t USING o,Sqlite3 通过 rowid 子查询
+func (b *Builder) getDeleteSqlWithJoin(tableName string, args []any) (string, []any, error) {
+	syntax := b.writeSyntax()
+	if syntax.DeleteJoin == driver.WriteJoin {
+		joinStr, args, err := b.handleJoin(args)
+		if err != nil {
+			return "", args, err
+		}
+
+		whereStr, args, err := b.handleWhere(args, true)
+		if err != nil {
+			return "", args, err
+		}
+
+		return "DELETE " + b.getUpdatePrefix(tableName) + " FROM " + b.getUpdateTable(tableName) + joinStr + whereStr, args, nil
+	}
+
+	if syntax.DeleteJoin == driver.WriteUsing {
+		fromStr, whereList, args, err := b.getJoinAsFrom(args)
+		if err != nil {
+			return "", args, err
+		}
+
+		query := "DELETE FROM " + b.getUpdateTable(tableName) + " USING " + fromStr
+		if len(whereList) > 0 {
+			query += " WHERE " + strings.Join(whereList, " AND ")
+		}
+
+		return query, args, nil
+	}
+
+	rowIdStr, args, err := b.getRowIdCondition(tableName, args)
+	if err != nil {
+		return "", args, err
+	}
+
+	return "DELETE FROM " + b.getUpdateTable(tableName) + " WHERE " + rowIdStr, args, nil
+}
+
+//isLimitWrite 更新或删除时是否需要处理排序与数量限制,除 Mysql 外只有排序没有数量限制时忽略排序
+func (b *Builder) isLimitWrite() bool {
+	if b.limitItem.pageSize > 0 {
+		return true
+	}
+
+	return b.writeSyntax().Limit == driver.WriteLimit && len(b.orderList) > 0
+}
+
+//getWriteLimit 追加在更新或删除之后的排序与数量限制,例如 Mysql,不支持偏移量
+func (b *Builder) getWriteLimit(args []any) (string, []any, error) {
+	if b.limitItem.offset > 0 {
+		return "", args, fmt.Errorf("%w: %s update or delete with offset", ErrNotSupported, b.Link.DriverName())
+	}
+
+	orderStr, args, err := b.handleOrder(args)
+	if err != nil {
+		return "", args, err
+	}
+
+	if b.limitItem.pageSize > 0 {
+		orderStr += " LIMIT ?"
+		args = append(args, b.limitItem.pageSize)
+	}
+
+	return orderStr, args, nil
+}
+
+//getWriteTop 没有排序时使用 TOP 的数量限制,例如 Mssql,不支持偏移量
+func (b *Builder) getWriteTop(args []any) (string, []any, error) {
+	if b.limitItem.offset > 0 {
+		return "", args, fmt.Errorf("%w: %s update or delete with offset but without order", ErrNotSupported, b.Link.DriverName())
+	}
+
+	return " TOP (?)", append(args, b.limitItem.pageSize), nil
+}
+
+//getWriteSubQuery 排序分页后的派生表,用于更新或删除,例如 Mssql
+func (b *Builder) getWriteSubQuery(tableName string, args []any) (string, []any, error) {
+	whereStr, args, err := b.handleWhere(args, true)
+	if err != nil {
+		return "", args, err
+	}
+
+	orderStr, args, err := b.handleOrder(args)
+	if err != nil {
+		return "", args, err
+	}
+
+	limitStr, args := b.handleLimit(args)
+
+	return "SELECT * FROM " + b.getUpdateTable(tableName) + whereStr + orderStr + limitStr, args, nil
+}
+
+//getRowIdCondition 按行号更新或删除,在子查询中完成关联,排序与分页,例如 Postgres 的 ctid,Sqlite3 的 rowid
+func (b *Builder) getRowIdCondition(tableName string, args []any) (string, []any, error) {
+	rowId := b.getUpdatePrefix(tableName) + "." + b.writeSyntax().RowId
+
+	joinStr, args, err := b.handleJoin(args)
+	if err != nil {
+		return "", args, err
+	}
+
+	whereStr, args, err := b.handleWhere(args, true)
+	if err != nil {
+		return "", args, err
+	}
+
+	orderStr, args, err := b.handleOrder(args)
+	if err != nil {
+		return "", args, err
+	}
+
+	limitStr, args := b.handleLimit(args)
+
+	return rowId + " IN (SELECT " + rowId + " FROM " + b.getUpdateTable(tableName) + joinStr + whereStr + orderStr + limitStr + ")", args, nil
+}
+
+//getJoinAsFrom 把关联转为 FROM/USING 的表与 WHERE 条件,只支持内联
+func (b *Builder) getJoinAsFrom(args []any) (string, []string, []any, error) {
+	var fromList []string
+	var conditionList []JoinItem
+	for i := 0; i < len(b.joinList); i++ {
+		joinItem := b.joinList[i]
+		if joinItem.joinType != "INNER JOIN" && joinItem.joinType != "CROSS JOIN" {
+			return "", nil, args, fmt.Errorf("%w: %s update or delete with %s", ErrNotSupported, b.Link.DriverName(), joinItem.joinType)
+		}
+
+		joinTableName, paramList, err := b.getJoinTableName(joinItem, args)
+		if err != nil {
+			return "", nil, args, err
+		}
+		args = paramList
+
+		tableAlias := ""
+		if len(joinItem.tableAlias) > 0 {
+			tableAlias = joinItem.tableAlias[0]
+		}
+
+		fromList = append(fromList, strings.TrimSpace(joinTableName+" "+b.quote(tableAlias)))
+		if len(joinItem.condition) > 0 {
+			conditionList = append(conditionList, joinItem)
+		}
+	}
+
+	var whereList []string
+	for i := 0; i < len(conditionList); i++ {
+		tableAlias := ""
+		if len(conditionList[i].tableAlias) > 0 {
+			tableAlias = conditionList[i].tableAlias[0]
+		}
+
+		str, paramList, err := b.genJoinConditionStr(tableAlias, conditionList[i].condition)
+		if err != nil {
+			return "", nil, args, err
+		}
+		whereList = append(whereList, "("+str+")")
+		args = append(args, paramList...)
+	}
+
+	whereStr, args, err := b.handleWhere(args, true)
+	if err != nil {
+		return "", nil, args, err
+	}
+	if whereStr != "" {
+		whereList = append(whereList, "("+strings.TrimPrefix(whereStr, " WHERE ")+")")
+	}
+
+	return strings.Join(fromList, ","), whereList, args, nil
+}
+
+//getWriteAlias 派生表的别名,有别名时使用别名,否则使用表名去掉库名的部分
+func (b *Builder) getWriteAlias(tableName string) string {
+	if b.tableAlias != "" {
+		return b.quote(b.tableAlias)
+	}
+	strArr := strings.Split(tableName, ".")
+	return strArr[len(strArr)-1]
+}
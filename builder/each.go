@@ -0,0 +1,141 @@
+package builder
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Each 逐行读取,每读取一行到 dest 后调用 fn, fn 返回错误则停止
+// 读取过程中连接一直被占用,在事务中请不要在 fn 里执行其他查询,可以使用 Chunk
+func (b *Builder) Each(dest interface{}, fn func() error) error {
+	_, rows, release, errRows := b.getRows(true)
+	if errRows != nil {
+		return errRows
+	}
+	defer release()
+	defer rows.Close()
+
+	destValue := reflect.ValueOf(dest).Elem()
+
+	//从数据库中读出来的字段名字
+	columnNameList, errColumns := rows.Columns()
+	if errColumns != nil {
+		return errColumns
+	}
+
+	//从结构体反射出来的属性名
+	fieldNameMap := getFieldMapByReflect(destValue.Type())
+	scans := getScansAddr(columnNameList, fieldNameMap, destValue)
+
+	for rows.Next() {
+		if err := rows.Scan(scans...); err != nil {
+			return err
+		}
+
+		if err := b.callHook(hookAfterFind, dest); err != nil {
+			return err
+		}
+
+		if err := fn(); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Chunk 按主键分批读取到 values, 每批最多 size 条后调用 fn, fn 返回错误则停止
+// 使用上一批最后的主键作为条件(keyset),而不是 offset, 原有的排序与分页会被忽略
+func (b *Builder) Chunk(size int, values interface{}, fn func() error) error {
+	if size <= 0 {
+		return ErrInvalidPageSize
+	}
+
+	destSlice := reflect.Indirect(reflect.ValueOf(values))
+	destType := destSlice.Type().Elem()
+
+	primaryIndex, primaryKey := getPrimaryKeyByReflect(destType)
+	if primaryIndex < 0 {
+		return ErrMissingPrimaryKey
+	}
+
+	prefix, err := b.getKeysetPrefix()
+	if err != nil {
+		return err
+	}
+
+	whereList := b.whereList
+	orderList := b.orderList
+	limitItem := b.limitItem
+	defer func() {
+		b.whereList = whereList
+		b.orderList = orderList
+		b.limitItem = limitItem
+	}()
+
+	var lastVal interface{}
+	for {
+		b.whereList = whereList
+		if lastVal != nil {
+			//原有条件放入条件组,避免其中的 OR 与主键条件混在一起
+			b.whereList = []WhereItem{{prefix, primaryKey, Gt, lastVal, And}}
+			if len(whereList) > 0 {
+				b.whereList = []WhereItem{{Opt: Group, Val: whereList, Logic: And}, b.whereList[0]}
+			}
+		}
+		b.orderList = []OrderItem{{prefix, primaryKey, Asc}}
+		b.limitItem = LimitItem{offset: 0, pageSize: size}
+
+		destSlice.Set(reflect.MakeSlice(destSlice.Type(), 0, size))
+		if err := b.GetMany(values); err != nil {
+			return err
+		}
+
+		count := destSlice.Len()
+		if count == 0 {
+			return nil
+		}
+
+		lastVal, _ = getValueByReflect(destSlice.Index(count - 1).Field(primaryIndex))
+
+		if err := fn(); err != nil {
+			return err
+		}
+
+		if count < size {
+			return nil
+		}
+	}
+}
+
+//getKeysetPrefix 主键条件与排序的前缀,有别名时使用别名,否则使用表名,避免关联查询时字段不明确
+func (b *Builder) getKeysetPrefix() ([]string, error) {
+	if b.tableAlias != "" {
+		return []string{b.tableAlias}, nil
+	}
+
+	//子查询必须有别名,没有表时由查询返回错误
+	if _, isSub := b.table.(**Builder); isSub || b.table == nil {
+		return nil, nil
+	}
+
+	tableName, err := b.getRegistry().getTableNameByTable(b.table)
+	if err != nil {
+		return nil, err
+	}
+
+	strArr := strings.Split(tableName, ".")
+	return []string{strArr[len(strArr)-1]}, nil
+}
+
+//getPrimaryKeyByReflect 从结构体反射出主键的位置与字段名
+func getPrimaryKeyByReflect(destType reflect.Type) (int, string) {
+	for i := 0; i < destType.NumField(); i++ {
+		key, tagMap := getFieldNameByStructField(destType.Field(i))
+		if _, ok := tagMap["primary"]; ok {
+			return i, key
+		}
+	}
+
+	return -1, ""
+}
@@ -0,0 +1,63 @@
+package builder
+
+import (
+	"errors"
+	"github.com/tangpanqing/aorm/driver"
+)
+
+var ErrNotFound = errors.New("NOT FOUND")
+var ErrMissingTable = errors.New("table name not found")
+var ErrMissingAlias = errors.New("table alias not found")
+var ErrEmptyBatch = errors.New("the data list for insert batch not found")
+var ErrUnregisteredField = errors.New("field is not registered")
+var ErrMissingPrimaryKey = errors.New("primary key not found")
+var ErrNotSupported = errors.New("not supported by this driver")
+var ErrMissingParam = errors.New("named parameter not found")
+var ErrInvalidPageSize = errors.New("page size must be greater than 0")
+var ErrMissingOrder = errors.New("order fields not found")
+var ErrInvalidCursor = errors.New("invalid cursor")
+var ErrNoFields = errors.New("no fields to write")
+var ErrMixedColumns = errors.New("rows in the batch have different columns")
+var ErrMissingConflictKey = errors.New("conflict column is not written")
+
+var ErrDuplicateKey = driver.ErrDuplicateKey
+var ErrForeignKeyViolation = driver.ErrForeignKeyViolation
+var ErrNotNullViolation = driver.ErrNotNullViolation
+var ErrDeadlock = driver.ErrDeadlock
+
+// DbError 数据库驱动返回的错误,可以用 errors.Is 判断类型,用 errors.As 获取驱动原始错误
+type DbError struct {
+	Kind error
+	Err  error
+}
+
+func (e *DbError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *DbError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DbError) Is(target error) bool {
+	return e.Kind == target
+}
+
+//normalizeError 将驱动返回的错误转成通用的错误类型,无法识别则原样返回
+func normalizeError(dialect driver.Dialect, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	kind := driver.GetErrorDialect(dialect).ErrorKind(err)
+	if kind == nil {
+		return err
+	}
+
+	return &DbError{Kind: kind, Err: err}
+}
+
+//wrapError 转换驱动错误
+func (b *Builder) wrapError(err error) error {
+	return normalizeError(b.dialect(), err)
+}
@@ -2,11 +2,11 @@ package builder
 
 // Exists 存在某记录
 func (b *Builder) Exists() (bool, error) {
-	stmt, rows, err := b.selectCommon("", "1", nil, "").Limit(0, 1).GetRows()
+	_, rows, release, err := b.selectCommon("", "1", nil, "").Limit(0, 1).getRows(true)
 	if err != nil {
 		return false, err
 	}
-	defer stmt.Close()
+	defer release()
 	defer rows.Close()
 
 	if rows.Next() {
@@ -0,0 +1,277 @@
+package builder
+
+import (
+	"github.com/tangpanqing/aorm/driver"
+	"strings"
+)
+
+const CastInt = driver.CastInt
+const CastFloat = driver.CastFloat
+const CastString = driver.CastString
+
+// Expr SQL表达式,可以用在 Select,Where,Having,OrderBy,GroupBy 等接收字段的地方
+type Expr interface {
+	toSql(b *Builder) (string, []interface{}, error)
+}
+
+type colExpr struct {
+	field  interface{}
+	prefix []string
+}
+
+type valExpr struct {
+	val interface{}
+}
+
+type rawExpr struct {
+	sql  string
+	args []interface{}
+}
+
+type opExpr struct {
+	left  interface{}
+	opt   string
+	right interface{}
+}
+
+type logicExpr struct {
+	logic string
+	list  []Expr
+}
+
+type funcExpr struct {
+	name string
+	args []interface{}
+}
+
+type castExpr struct {
+	val interface{}
+	typ string
+}
+
+type caseItem struct {
+	cond Expr
+	then interface{}
+}
+
+// CaseExpr CASE WHEN 表达式
+type CaseExpr struct {
+	whenList []caseItem
+	elseVal  interface{}
+	hasElse  bool
+}
+
+// Col 字段表达式,例如 Col(&person.Age)
+func Col(field interface{}, prefix ...string) Expr {
+	return &colExpr{field, prefix}
+}
+
+// Val 值表达式,作为参数绑定
+func Val(val interface{}) Expr {
+	return &valExpr{val}
+}
+
+// RawExpr 原生表达式,例如 RawExpr("age + ?", 1)
+func RawExpr(sql string, args ...interface{}) Expr {
+	return &rawExpr{sql, args}
+}
+
+// Add 加法,参数不是表达式时作为值绑定
+func Add(left interface{}, right interface{}) Expr {
+	return &opExpr{left, "+", right}
+}
+
+// Sub 减法
+func Sub(left interface{}, right interface{}) Expr {
+	return &opExpr{left, "-", right}
+}
+
+// Mul 乘法
+func Mul(left interface{}, right interface{}) Expr {
+	return &opExpr{left, "*", right}
+}
+
+// Div 除法
+func Div(left interface{}, right interface{}) Expr {
+	return &opExpr{left, "/", right}
+}
+
+// Cmp 比较,例如 Cmp(Col(&person.Age), builder.Gt, 18)
+func Cmp(left interface{}, opt string, right interface{}) Expr {
+	return &opExpr{left, opt, right}
+}
+
+// AllOf 多个条件以 AND 连接
+func AllOf(list ...Expr) Expr {
+	return &logicExpr{And, list}
+}
+
+// AnyOf 多个条件以 OR 连接
+func AnyOf(list ...Expr) Expr {
+	return &logicExpr{Or, list}
+}
+
+// Func 函数调用,例如 Func("UPPER", Col(&person.Name))
+func Func(name string, args ...interface{}) Expr {
+	return &funcExpr{name, args}
+}
+
+// Coalesce 返回第一个非空值
+func Coalesce(args ...interface{}) Expr {
+	return &funcExpr{"COALESCE", args}
+}
+
+// Cast 类型转换,typ 为 CastInt,CastFloat,CastString 时按数据库转换,其他原样使用
+func Cast(val interface{}, typ string) Expr {
+	return &castExpr{val, typ}
+}
+
+// CaseWhen CASE WHEN 表达式,例如 CaseWhen(Cmp(Col(&person.Age), builder.Ge, 18), "adult").Else("child")
+func CaseWhen(cond Expr, then interface{}) *CaseExpr {
+	return &CaseExpr{whenList: []caseItem{{cond, then}}}
+}
+
+// When 增加一个分支
+func (c *CaseExpr) When(cond Expr, then interface{}) *CaseExpr {
+	c.whenList = append(c.whenList, caseItem{cond, then})
+	return c
+}
+
+// Else 其他情况的值
+func (c *CaseExpr) Else(val interface{}) *CaseExpr {
+	c.elseVal = val
+	c.hasElse = true
+	return c
+}
+
+func (e *colExpr) toSql(b *Builder) (string, []interface{}, error) {
+	prefix, err := b.getPrefix(e.field, e.prefix...)
+	if err != nil {
+		return "", nil, err
+	}
+	if prefix != "" {
+		prefix += "."
+	}
+
+	fieldName, err := b.getFieldName(e.field)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return prefix + fieldName, nil, nil
+}
+
+func (e *valExpr) toSql(b *Builder) (string, []interface{}, error) {
+	return "?", []interface{}{e.val}, nil
+}
+
+func (e *rawExpr) toSql(b *Builder) (string, []interface{}, error) {
+	return e.sql, e.args, nil
+}
+
+func (e *opExpr) toSql(b *Builder) (string, []interface{}, error) {
+	left, args, err := b.getOperandSql(e.left, nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	right, args, err := b.getOperandSql(e.right, args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return "(" + left + " " + e.opt + " " + right + ")", args, nil
+}
+
+func (e *logicExpr) toSql(b *Builder) (string, []interface{}, error) {
+	var args []interface{}
+	var strList []string
+	for i := 0; i < len(e.list); i++ {
+		str, exprArgs, err := e.list[i].toSql(b)
+		if err != nil {
+			return "", nil, err
+		}
+		strList = append(strList, str)
+		args = append(args, exprArgs...)
+	}
+
+	return "(" + strings.Join(strList, " "+e.logic+" ") + ")", args, nil
+}
+
+func (e *funcExpr) toSql(b *Builder) (string, []interface{}, error) {
+	var args []interface{}
+	var strList []string
+	for i := 0; i < len(e.args); i++ {
+		str, exprArgs, err := b.getOperandSql(e.args[i], args)
+		if err != nil {
+			return "", nil, err
+		}
+		strList = append(strList, str)
+		args = exprArgs
+	}
+
+	return e.name + "(" + strings.Join(strList, ",") + ")", args, nil
+}
+
+func (e *castExpr) toSql(b *Builder) (string, []interface{}, error) {
+	str, args, err := b.getOperandSql(e.val, nil)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return "CAST(" + str + " AS " + b.queryDialect().CastType(e.typ) + ")", args, nil
+}
+
+func (c *CaseExpr) toSql(b *Builder) (string, []interface{}, error) {
+	var args []interface{}
+	str := "CASE"
+	for i := 0; i < len(c.whenList); i++ {
+		cond, condArgs, err := c.whenList[i].cond.toSql(b)
+		if err != nil {
+			return "", nil, err
+		}
+		args = append(args, condArgs...)
+
+		then, thenArgs, err := b.getOperandSql(c.whenList[i].then, args)
+		if err != nil {
+			return "", nil, err
+		}
+		args = thenArgs
+
+		str += " WHEN " + cond + " THEN " + then
+	}
+
+	if c.hasElse {
+		elseStr, elseArgs, err := b.getOperandSql(c.elseVal, args)
+		if err != nil {
+			return "", nil, err
+		}
+		args = elseArgs
+
+		str += " ELSE " + elseStr
+	}
+
+	return str + " END", args, nil
+}
+
+//getOperandSql 获取操作数的sql,表达式直接拼接,其他作为值绑定
+func (b *Builder) getOperandSql(operand interface{}, args []interface{}) (string, []interface{}, error) {
+	if expr, ok := operand.(Expr); ok {
+		str, exprArgs, err := expr.toSql(b)
+		if err != nil {
+			return "", args, err
+		}
+		return str, append(args, exprArgs...), nil
+	}
+
+	return "?", append(args, operand), nil
+}
+
+//getFieldSql 获取字段的sql,支持表达式与字段指针,字段指针会加上前缀
+func (b *Builder) getFieldSql(field interface{}, prefix ...string) (string, []interface{}, error) {
+	if expr, ok := field.(Expr); ok {
+		return expr.toSql(b)
+	}
+
+	return Col(field, prefix...).toSql(b)
+}
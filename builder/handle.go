@@ -1,33 +1,33 @@
 package builder
 
 import (
-	"errors"
 	"fmt"
-	"github.com/tangpanqing/aorm/driver"
 	"reflect"
 	"strings"
 )
 
-func handleSelectWith(selectItem SelectItem) string {
+func (b *Builder) handleSelectWith(selectItem SelectItem) (string, []interface{}, error) {
+	if selectItem.Over != nil {
+		return b.handleOverWith(selectItem)
+	}
+
 	str := ""
 	if selectItem.FuncName != "" {
 		str += selectItem.FuncName
 		str += "("
 	}
 
-	valueOfField := reflect.ValueOf(selectItem.Field)
-	prefix := getPrefixByField(valueOfField, selectItem.Prefix...)
-	if prefix != "" {
-		str += prefix + "."
+	fieldStr, paramList, err := b.getFieldSql(selectItem.Field, selectItem.Prefix...)
+	if err != nil {
+		return "", nil, err
 	}
-
-	str += getFieldNameByReflectValue(valueOfField)
+	str += fieldStr
 
 	if selectItem.FuncName != "" {
 		str += ")"
 	}
 
-	return str
+	return str, paramList, nil
 }
 
 //拼接SQL,字段相关
@@ -48,11 +48,20 @@ func (b *Builder) handleSelect(paramList []any) (string, []any, error) {
 	for i := 0; i < len(b.selectList); i++ {
 		selectItem := b.selectList[i]
 
-		str := handleSelectWith(selectItem)
+		str, selectParamList, err := b.handleSelectWith(selectItem)
+		if err != nil {
+			return "", paramList, err
+		}
+		paramList = append(paramList, selectParamList...)
 
 		if selectItem.FieldNew != nil {
+			fieldNameNew, err := b.getFieldName(selectItem.FieldNew)
+			if err != nil {
+				return "", paramList, err
+			}
+
 			str += " AS "
-			str += getFieldNameByField(selectItem.FieldNew)
+			str += fieldNameNew
 		}
 
 		strList = append(strList, str)
@@ -65,7 +74,11 @@ func (b *Builder) handleSelect(paramList []any) (string, []any, error) {
 		if err != nil {
 			return "", paramList, err
 		}
-		strList = append(strList, "("+subSql+") AS "+getFieldNameByField(b.selectExpList[i].FieldName))
+		fieldName, err := b.getFieldName(b.selectExpList[i].FieldName)
+		if err != nil {
+			return "", paramList, err
+		}
+		strList = append(strList, "("+subSql+") AS "+fieldName)
 		paramList = append(paramList, subParamList...)
 	}
 
@@ -75,7 +88,7 @@ func (b *Builder) handleSelect(paramList []any) (string, []any, error) {
 
 func (b *Builder) handleTable(paramList []any) (string, []any, error) {
 	if b.table == nil {
-		return "", paramList, errors.New("表不能为空")
+		return "", paramList, ErrMissingTable
 	}
 
 	var tableName string
@@ -84,10 +97,14 @@ func (b *Builder) handleTable(paramList []any) (string, []any, error) {
 	if reflect.Ptr == valueOf.Kind() {
 
 		if "**builder.Builder" != valueOf.Type().String() {
-			tableName = getTableMap(valueOf.Pointer())
+			name, err := b.getTableName(b.table)
+			if err != nil {
+				return "", paramList, err
+			}
+			tableName = name
 		} else {
 			if b.tableAlias == "" {
-				return "", paramList, errors.New("别名不能为空")
+				return "", paramList, ErrMissingAlias
 			}
 
 			subBuilder := *(**Builder)(valueOf.UnsafePointer())
@@ -100,10 +117,10 @@ func (b *Builder) handleTable(paramList []any) (string, []any, error) {
 			paramList = append(paramList, subParamList...)
 		}
 	} else {
-		tableName = fmt.Sprintf("%v", b.table)
+		tableName = b.quote(fmt.Sprintf("%v", b.table))
 	}
 
-	return " FROM " + tableName + " " + b.tableAlias, paramList, nil
+	return " FROM " + tableName + " " + b.quote(b.tableAlias), paramList, nil
 }
 
 //拼接SQL,查询条件
@@ -112,42 +129,62 @@ func (b *Builder) handleWhere(paramList []any, needPrefix bool) (string, []any,
 		return "", paramList, nil
 	}
 
-	strList, paramList, err := b.whereAndHaving(b.whereList, paramList, false, needPrefix)
+	whereStr, paramList, err := b.whereAndHaving(b.whereList, paramList, false, needPrefix)
 	if err != nil {
+		return "", paramList, err
+	}
+
+	if whereStr == "" {
 		return "", paramList, nil
 	}
 
-	return " WHERE " + strings.Join(strList, " AND "), paramList, nil
+	return " WHERE " + whereStr, paramList, nil
 }
 
-//拼接SQL,更新信息
-func (b *Builder) handleSet(typeOf reflect.Type, valueOf reflect.Value, paramList []any) (string, []any) {
-
-	//如果没有设置表名
-	if b.table == nil {
-		b.table = getTableNameByReflect(typeOf, valueOf)
+//拼接SQL,更新信息,prefix 不为空时字段带上前缀,没有字段时返回 ErrNoFields
+func (b *Builder) handleSet(setList []SetItem, prefix string, paramList []any) (string, []any, error) {
+	if len(setList) == 0 {
+		return "", paramList, ErrNoFields
 	}
 
 	var keys []string
-	for i := 0; i < typeOf.Elem().NumField(); i++ {
-		isNotNull := valueOf.Elem().Field(i).Field(0).Field(1).Bool()
-		if isNotNull {
-			key, _ := getFieldNameByStructField(typeOf.Elem().Field(i))
-
-			val := valueOf.Elem().Field(i).Field(0).Field(0).Interface()
+	for i := 0; i < len(setList); i++ {
+		key, err := b.getFieldName(setList[i].Field)
+		if err != nil {
+			return "", paramList, err
+		}
+		if prefix != "" {
+			key = b.quote(prefix) + "." + key
+		}
 
+		switch val := setList[i].Val.(type) {
+		case Expr:
+			exprSql, exprParamList, err := val.toSql(b)
+			if err != nil {
+				return "", paramList, err
+			}
+			keys = append(keys, key+"="+exprSql)
+			paramList = append(paramList, exprParamList...)
+		case **Builder:
+			subSql, subParamList, err := (*val).GetSqlAndParams()
+			if err != nil {
+				return "", paramList, err
+			}
+			keys = append(keys, key+"=("+subSql+")")
+			paramList = append(paramList, subParamList...)
+		default:
 			keys = append(keys, key+"=?")
 			paramList = append(paramList, val)
 		}
 	}
 
-	return " SET " + strings.Join(keys, ","), paramList
+	return " SET " + strings.Join(keys, ","), paramList, nil
 }
 
 //拼接SQL,关联查询
-func (b *Builder) handleJoin(paramList []interface{}) (string, []interface{}) {
+func (b *Builder) handleJoin(paramList []interface{}) (string, []interface{}, error) {
 	if len(b.joinList) == 0 {
-		return "", paramList
+		return "", paramList, nil
 	}
 
 	var sqlList []string
@@ -159,34 +196,66 @@ func (b *Builder) handleJoin(paramList []interface{}) (string, []interface{}) {
 			tableAlias = joinItem.tableAlias[0]
 		}
 
-		str, paramList2 := genJoinConditionStr(tableAlias, joinItem.condition)
-		paramList = append(paramList, paramList2...)
+		var tableName string
+		var err error
+		tableName, paramList, err = b.getJoinTableName(joinItem, paramList)
+		if err != nil {
+			return "", paramList, err
+		}
 
-		sqlItem := joinItem.joinType + " " + getTableNameByTable(joinItem.table) + " " + tableAlias + " ON " + str
+		sqlItem := joinItem.joinType + " " + tableName + " " + b.quote(tableAlias)
+		if len(joinItem.condition) > 0 {
+			str, paramList2, err := b.genJoinConditionStr(tableAlias, joinItem.condition)
+			if err != nil {
+				return "", paramList, err
+			}
+			paramList = append(paramList, paramList2...)
+
+			sqlItem += " ON " + str
+		}
 		sqlList = append(sqlList, sqlItem)
 	}
 
-	return " " + strings.Join(sqlList, " "), paramList
+	return " " + strings.Join(sqlList, " "), paramList, nil
+}
+
+//getJoinTableName 获取关联的表名,子查询需要别名
+func (b *Builder) getJoinTableName(joinItem JoinItem, paramList []interface{}) (string, []interface{}, error) {
+	subBuilder, ok := joinItem.table.(**Builder)
+	if !ok {
+		tableName, err := b.getTableName(joinItem.table)
+		return tableName, paramList, err
+	}
+
+	if len(joinItem.tableAlias) == 0 || joinItem.tableAlias[0] == "" {
+		return "", paramList, ErrMissingAlias
+	}
+
+	subSql, subParamList, err := (*subBuilder).GetSqlAndParams()
+	if err != nil {
+		return "", paramList, err
+	}
+
+	return "(" + subSql + ")", append(paramList, subParamList...), nil
 }
 
 //拼接SQL,结果分组
-func (b *Builder) handleGroup(paramList []any) (string, []any) {
+func (b *Builder) handleGroup(paramList []any) (string, []any, error) {
 	if len(b.groupList) == 0 {
-		return "", paramList
+		return "", paramList, nil
 	}
 
 	var groupList []string
 	for i := 0; i < len(b.groupList); i++ {
-		valueOfField := reflect.ValueOf(b.groupList[i].Field)
-		prefix := getPrefixByField(valueOfField, b.groupList[i].Prefix...)
-		if prefix != "" {
-			prefix += "."
+		field, fieldParamList, err := b.getFieldSql(b.groupList[i].Field, b.groupList[i].Prefix...)
+		if err != nil {
+			return "", paramList, err
 		}
-		field := getFieldNameByReflectValue(valueOfField)
-		groupList = append(groupList, prefix+field)
+		groupList = append(groupList, field)
+		paramList = append(paramList, fieldParamList...)
 	}
 
-	return " GROUP BY " + strings.Join(groupList, ","), paramList
+	return " GROUP BY " + strings.Join(groupList, ","), paramList, nil
 }
 
 //拼接SQL,结果筛选
@@ -195,29 +264,48 @@ func (b *Builder) handleHaving(paramList []any) (string, []any, error) {
 		return "", paramList, nil
 	}
 
-	strList, paramList, err := b.whereAndHaving(b.havingList, paramList, true, true)
+	havingStr, paramList, err := b.whereAndHaving(b.havingList, paramList, true, true)
 	if err != nil {
 		return "", paramList, err
 	}
 
-	return " Having " + strings.Join(strList, " AND "), paramList, nil
+	if havingStr == "" {
+		return "", paramList, nil
+	}
+
+	return " Having " + havingStr, paramList, nil
 }
 
 //拼接SQL,结果排序
-func (b *Builder) handleOrder(paramList []any) (string, []any) {
+func (b *Builder) handleOrder(paramList []any) (string, []any, error) {
 	if len(b.orderList) == 0 {
-		return "", paramList
+		return "", paramList, nil
 	}
 
 	var orderList []string
 	for i := 0; i < len(b.orderList); i++ {
-		valueOfField := reflect.ValueOf(b.orderList[i].Field)
-		prefix := getPrefixByField(valueOfField, b.orderList[i].Prefix...)
-		field := getFieldNameByReflectValue(valueOfField)
-		orderList = append(orderList, prefix+"."+field+" "+b.orderList[i].OrderType)
+		var field string
+
+		//查询字段的别名,例如窗口函数,不需要前缀
+		if len(b.orderList[i].Prefix) == 0 && b.isSelectAlias(b.orderList[i].Field) {
+			fieldName, err := b.getFieldName(b.orderList[i].Field)
+			if err != nil {
+				return "", paramList, err
+			}
+			field = fieldName
+		} else {
+			fieldSql, fieldParamList, err := b.getFieldSql(b.orderList[i].Field, b.orderList[i].Prefix...)
+			if err != nil {
+				return "", paramList, err
+			}
+			field = fieldSql
+			paramList = append(paramList, fieldParamList...)
+		}
+
+		orderList = append(orderList, field+" "+b.orderList[i].OrderType)
 	}
 
-	return " ORDER BY " + strings.Join(orderList, ","), paramList
+	return " ORDER BY " + strings.Join(orderList, ","), paramList, nil
 }
 
 //拼接SQL,分页相关  Postgres数据库分页数量在前偏移在后，其他数据库偏移量在前分页数量在后，另外Mssql数据库的关键词是offset...next
@@ -226,29 +314,15 @@ func (b *Builder) handleLimit(paramList []any) (string, []any) {
 		return "", paramList
 	}
 
-	str := ""
-	if b.Link.DriverName() == driver.Postgres {
-		paramList = append(paramList, b.limitItem.pageSize)
-		paramList = append(paramList, b.limitItem.offset)
-
-		str = " Limit ? offset ? "
-	} else {
-		paramList = append(paramList, b.limitItem.offset)
-		paramList = append(paramList, b.limitItem.pageSize)
-
-		str = " Limit ?,? "
-		if b.Link.DriverName() == driver.Mssql {
-			str = " offset ? rows fetch next ? rows only "
-		}
-	}
+	str, limitArgs := b.dialect().Limit(b.limitItem.offset, b.limitItem.pageSize)
 
-	return str, paramList
+	return str, append(paramList, limitArgs...)
 }
 
 //拼接SQL,锁
 func (b *Builder) handleLockForUpdate() string {
 	if b.isLockForUpdate {
-		return " FOR UPDATE"
+		return b.dialect().LockForUpdate()
 	}
 
 	return ""
@@ -6,6 +6,7 @@ import (
 )
 
 // Having 链式操作,以对象作为筛选条件
+// 普通类型的字段为零值时视为未设置,不会作为条件,需要时请使用 HavingEq 指定字段
 func (b *Builder) Having(dest interface{}) *Builder {
 	typeOf := reflect.TypeOf(dest)
 	valueOf := reflect.ValueOf(dest)
@@ -16,10 +17,13 @@ func (b *Builder) Having(dest interface{}) *Builder {
 	}
 
 	for i := 0; i < typeOf.Elem().NumField(); i++ {
-		isNotNull := valueOf.Elem().Field(i).Field(0).Field(1).Bool()
+		if !typeOf.Elem().Field(i).IsExported() {
+			continue
+		}
+
+		val, isNotNull := getWhereValueByReflect(valueOf.Elem().Field(i))
 		if isNotNull {
 			key := utils.UnderLine(typeOf.Elem().Field(i).Name)
-			val := valueOf.Elem().Field(i).Field(0).Field(0).Interface()
 			b.havingList = append(b.havingList, WhereItem{Field: key, Opt: Eq, Val: val})
 		}
 	}
@@ -81,11 +85,95 @@ func (b *Builder) HavingNotLike(field interface{}, val interface{}) *Builder {
 	return b.havingItemAppend(field, NotLike, val)
 }
 
-func (b *Builder) HavingRaw(val interface{}) *Builder {
+func (b *Builder) HavingRaw(val interface{}, args ...interface{}) *Builder {
+	if query, ok := val.(string); ok && len(args) > 0 {
+		return b.havingItemAppend("", Raw, rawSql{query, args})
+	}
 	return b.havingItemAppend("", Raw, val)
 }
 
+func (b *Builder) HavingOrEq(field interface{}, val interface{}) *Builder {
+	return b.havingOrItemAppend(field, Eq, val)
+}
+
+func (b *Builder) HavingOrNe(field interface{}, val interface{}) *Builder {
+	return b.havingOrItemAppend(field, Ne, val)
+}
+
+func (b *Builder) HavingOrGt(field interface{}, val interface{}) *Builder {
+	return b.havingOrItemAppend(field, Gt, val)
+}
+
+func (b *Builder) HavingOrGe(field interface{}, val interface{}) *Builder {
+	return b.havingOrItemAppend(field, Ge, val)
+}
+
+func (b *Builder) HavingOrLt(field interface{}, val interface{}) *Builder {
+	return b.havingOrItemAppend(field, Lt, val)
+}
+
+func (b *Builder) HavingOrLe(field interface{}, val interface{}) *Builder {
+	return b.havingOrItemAppend(field, Le, val)
+}
+
+func (b *Builder) HavingOrIn(field interface{}, val interface{}) *Builder {
+	return b.havingOrItemAppend(field, In, val)
+}
+
+func (b *Builder) HavingOrNotIn(field interface{}, val interface{}) *Builder {
+	return b.havingOrItemAppend(field, NotIn, val)
+}
+
+func (b *Builder) HavingOrBetween(field interface{}, val interface{}) *Builder {
+	return b.havingOrItemAppend(field, Between, val)
+}
+
+func (b *Builder) HavingOrNotBetween(field interface{}, val interface{}) *Builder {
+	return b.havingOrItemAppend(field, NotBetween, val)
+}
+
+func (b *Builder) HavingOrLike(field interface{}, val interface{}) *Builder {
+	return b.havingOrItemAppend(field, Like, val)
+}
+
+func (b *Builder) HavingOrNotLike(field interface{}, val interface{}) *Builder {
+	return b.havingOrItemAppend(field, NotLike, val)
+}
+
+func (b *Builder) HavingOrRaw(val interface{}, args ...interface{}) *Builder {
+	if query, ok := val.(string); ok && len(args) > 0 {
+		return b.havingOrItemAppend("", Raw, rawSql{query, args})
+	}
+	return b.havingOrItemAppend("", Raw, val)
+}
+
+// HavingGroup 链式操作,以 AND 连接一组带括号的筛选条件
+func (b *Builder) HavingGroup(fn func(*Builder)) *Builder {
+	return b.havingGroupAppend(And, fn)
+}
+
+// OrHavingGroup 链式操作,以 OR 连接一组带括号的筛选条件
+func (b *Builder) OrHavingGroup(fn func(*Builder)) *Builder {
+	return b.havingGroupAppend(Or, fn)
+}
+
 func (b *Builder) havingItemAppend(field interface{}, opt string, val interface{}) *Builder {
-	b.havingList = append(b.havingList, WhereItem{[]string{""}, field, opt, val})
+	b.havingList = append(b.havingList, WhereItem{[]string{""}, field, opt, val, And})
+	return b
+}
+
+func (b *Builder) havingOrItemAppend(field interface{}, opt string, val interface{}) *Builder {
+	b.havingList = append(b.havingList, WhereItem{[]string{""}, field, opt, val, Or})
+	return b
+}
+
+func (b *Builder) havingGroupAppend(logic string, fn func(*Builder)) *Builder {
+	sub := b.newGroupBuilder()
+	fn(sub)
+	if sub.err != nil && b.err == nil {
+		b.err = sub.err
+	}
+
+	b.havingList = append(b.havingList, WhereItem{Opt: Group, Val: sub.havingList, Logic: logic})
 	return b
 }
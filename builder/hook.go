@@ -0,0 +1,108 @@
+package builder
+
+import (
+	"github.com/tangpanqing/aorm/base"
+	"reflect"
+)
+
+// BeforeInsertHook 增加记录前执行,返回错误则终止
+type BeforeInsertHook interface {
+	BeforeInsert(link base.Link) error
+}
+
+// AfterInsertHook 增加记录后执行
+type AfterInsertHook interface {
+	AfterInsert(link base.Link) error
+}
+
+// BeforeUpdateHook 更新记录前执行,返回错误则终止
+type BeforeUpdateHook interface {
+	BeforeUpdate(link base.Link) error
+}
+
+// AfterUpdateHook 更新记录后执行
+type AfterUpdateHook interface {
+	AfterUpdate(link base.Link) error
+}
+
+// BeforeDeleteHook 删除记录前执行,返回错误则终止
+type BeforeDeleteHook interface {
+	BeforeDelete(link base.Link) error
+}
+
+// AfterDeleteHook 删除记录后执行
+type AfterDeleteHook interface {
+	AfterDelete(link base.Link) error
+}
+
+// AfterFindHook 查询记录后执行
+type AfterFindHook interface {
+	AfterFind(link base.Link) error
+}
+
+const hookBeforeInsert = "BeforeInsert"
+const hookAfterInsert = "AfterInsert"
+const hookBeforeUpdate = "BeforeUpdate"
+const hookAfterUpdate = "AfterUpdate"
+const hookBeforeDelete = "BeforeDelete"
+const hookAfterDelete = "AfterDelete"
+const hookAfterFind = "AfterFind"
+
+//callHook 如果对象实现了对应的钩子,则执行,出错时如果在事务中,回滚事务
+func (b *Builder) callHook(hookName string, dest interface{}) error {
+	var err error
+	switch hookName {
+	case hookBeforeInsert:
+		if h, ok := dest.(BeforeInsertHook); ok {
+			err = h.BeforeInsert(b.Link)
+		}
+	case hookAfterInsert:
+		if h, ok := dest.(AfterInsertHook); ok {
+			err = h.AfterInsert(b.Link)
+		}
+	case hookBeforeUpdate:
+		if h, ok := dest.(BeforeUpdateHook); ok {
+			err = h.BeforeUpdate(b.Link)
+		}
+	case hookAfterUpdate:
+		if h, ok := dest.(AfterUpdateHook); ok {
+			err = h.AfterUpdate(b.Link)
+		}
+	case hookBeforeDelete:
+		if h, ok := dest.(BeforeDeleteHook); ok {
+			err = h.BeforeDelete(b.Link)
+		}
+	case hookAfterDelete:
+		if h, ok := dest.(AfterDeleteHook); ok {
+			err = h.AfterDelete(b.Link)
+		}
+	case hookAfterFind:
+		if h, ok := dest.(AfterFindHook); ok {
+			err = h.AfterFind(b.Link)
+		}
+	}
+
+	if err != nil {
+		if tx, ok := b.Link.(*base.Tx); ok {
+			tx.Rollback()
+		}
+	}
+
+	return err
+}
+
+//callHookForSlice 对切片中的每一条记录执行钩子,从 start 位置开始
+func (b *Builder) callHookForSlice(hookName string, destSlice reflect.Value, start int) error {
+	for i := start; i < destSlice.Len(); i++ {
+		item := destSlice.Index(i)
+		if item.Kind() != reflect.Ptr {
+			item = item.Addr()
+		}
+
+		if err := b.callHook(hookName, item.Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
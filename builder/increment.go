@@ -1,9 +1,7 @@
 package builder
 
-import "errors"
-
-// Increment 某字段自增
-func (b *Builder) Increment(field interface{}, step int) (int64, error) {
+// Increment 某字段自增,step 可以是整数,浮点数或 decimal
+func (b *Builder) Increment(field interface{}, step interface{}) (int64, error) {
 	var vars []any
 	vars = append(vars, step)
 	whereStr, vars, err := b.handleWhere(vars, false)
@@ -12,14 +10,24 @@ func (b *Builder) Increment(field interface{}, step int) (int64, error) {
 	}
 
 	if b.table == nil {
-		return 0, errors.New("表名不能为空")
+		return 0, ErrMissingTable
 	}
-	query := "UPDATE " + getTableNameByTable(b.table) + " SET " + getFieldNameByField(field) + "=" + getFieldNameByField(field) + "+?" + whereStr
+	tableName, err := b.getTableName(b.table)
+	if err != nil {
+		return 0, err
+	}
+
+	fieldName, err := b.getFieldName(field)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "UPDATE " + tableName + " SET " + fieldName + "=" + fieldName + "+?" + whereStr
 	return b.execAffected(query, vars...)
 }
 
-// Decrement 某字段自减
-func (b *Builder) Decrement(field interface{}, step int) (int64, error) {
+// Decrement 某字段自减,step 可以是整数,浮点数或 decimal
+func (b *Builder) Decrement(field interface{}, step interface{}) (int64, error) {
 	var vars []any
 	vars = append(vars, step)
 	whereStr, vars, err := b.handleWhere(vars, false)
@@ -28,8 +36,18 @@ func (b *Builder) Decrement(field interface{}, step int) (int64, error) {
 	}
 
 	if b.table == nil {
-		return 0, errors.New("表名不能为空")
+		return 0, ErrMissingTable
 	}
-	query := "UPDATE " + getTableNameByTable(b.table) + " SET " + getFieldNameByField(field) + "=" + getFieldNameByField(field) + "-?" + whereStr
+	tableName, err := b.getTableName(b.table)
+	if err != nil {
+		return 0, err
+	}
+
+	fieldName, err := b.getFieldName(field)
+	if err != nil {
+		return 0, err
+	}
+
+	query := "UPDATE " + tableName + " SET " + fieldName + "=" + fieldName + "-?" + whereStr
 	return b.execAffected(query, vars...)
 }
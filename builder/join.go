@@ -15,6 +15,16 @@ func (b *Builder) Join(table interface{}, condition []JoinCondition, alias ...st
 	return b.join("INNER JOIN", table, condition, alias...)
 }
 
+// FullJoin 链式操作,全外联查询,Mysql 不支持
+func (b *Builder) FullJoin(table interface{}, condition []JoinCondition, alias ...string) *Builder {
+	return b.join("FULL OUTER JOIN", table, condition, alias...)
+}
+
+// CrossJoin 链式操作,交叉联查询,没有关联条件
+func (b *Builder) CrossJoin(table interface{}, alias ...string) *Builder {
+	return b.join("CROSS JOIN", table, nil, alias...)
+}
+
 func (b *Builder) join(joinType string, table interface{}, condition []JoinCondition, alias ...string) *Builder {
 	b.joinList = append(b.joinList, JoinItem{joinType, table, alias, condition})
 	return b
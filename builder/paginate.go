@@ -0,0 +1,124 @@
+package builder
+
+import "github.com/tangpanqing/aorm/driver"
+
+const totalAlias = "aorm_total"
+
+// Pagination 分页结果
+type Pagination struct {
+	Total int64
+	Page  int
+	Size  int
+	Pages int
+}
+
+// Paginate 分页查询,查询当前页的记录,并查询总数
+func (b *Builder) Paginate(pageNum int, pageSize int, values interface{}) (Pagination, error) {
+	if pageSize <= 0 {
+		return Pagination{}, ErrInvalidPageSize
+	}
+	if pageNum < 1 {
+		pageNum = 1
+	}
+
+	total, err := b.countForPaginate()
+	if err != nil {
+		return Pagination{}, err
+	}
+
+	if total > int64((pageNum-1)*pageSize) {
+		if err = b.Page(pageNum, pageSize).GetMany(values); err != nil {
+			return Pagination{}, err
+		}
+	}
+
+	return newPagination(total, pageNum, pageSize), nil
+}
+
+// PaginateOver 分页查询,通过 COUNT(*) OVER() 在同一次查询中获取总数,数据库不支持或者有去重,合并时使用 Paginate
+func (b *Builder) PaginateOver(pageNum int, pageSize int, values interface{}) (Pagination, error) {
+	supported, err := b.supports(driver.FeatureWindow, false)
+	if err != nil {
+		return Pagination{}, err
+	}
+	if !supported || b.distinct || len(b.unionList) > 0 {
+		return b.Paginate(pageNum, pageSize, values)
+	}
+	if pageSize <= 0 {
+		return Pagination{}, ErrInvalidPageSize
+	}
+	if pageNum < 1 {
+		pageNum = 1
+	}
+
+	if len(b.selectList) == 0 && len(b.selectExpList) == 0 {
+		b.selectCommon("", RawExpr("*"), nil)
+	}
+	b.SelectOver("COUNT", RawExpr("*"), totalAlias, nil)
+
+	var total int64
+	count, err := b.Page(pageNum, pageSize).getMany(values, map[string]interface{}{totalAlias: &total})
+	if err != nil {
+		return Pagination{}, err
+	}
+
+	//当前页没有记录时无法得到总数,需要单独查询
+	if count == 0 && pageNum > 1 {
+		total, err = b.countForPaginate()
+		if err != nil {
+			return Pagination{}, err
+		}
+	}
+
+	return newPagination(total, pageNum, pageSize), nil
+}
+
+//countForPaginate 查询分页的总数,去掉排序,分页与加锁,有分组,去重或合并时作为子查询统计
+func (b *Builder) countForPaginate() (int64, error) {
+	cb := *b
+	cb.orderList = nil
+	cb.limitItem = LimitItem{}
+	cb.isLockForUpdate = false
+
+	if len(b.groupList) == 0 && len(b.havingList) == 0 && !b.distinct && len(b.unionList) == 0 {
+		cb.selectList = nil
+		cb.selectExpList = nil
+		cb.selectCommon("", RawExpr("COUNT(*)"), "c")
+
+		var obj []IntStruct
+		if err := cb.GetMany(&obj); err != nil {
+			return 0, err
+		}
+		return obj[0].C.Int64, nil
+	}
+
+	//WITH 放在最外层,部分数据库不支持子查询中使用,不使用原始sql,统计与查询一样可以发往从库
+	sub := &cb
+	sub.withList = nil
+	ob := &Builder{
+		Link:           b.Link,
+		ctx:            b.ctx,
+		registry:       b.registry,
+		withList:       b.withList,
+		isDebug:        b.isDebug,
+		isForcePrimary: b.isForcePrimary,
+	}
+	ob.Table(&sub, totalAlias)
+	ob.selectCommon("", RawExpr("COUNT(*)"), "c")
+
+	var obj []IntStruct
+	if err := ob.GetMany(&obj); err != nil {
+		return 0, err
+	}
+	return obj[0].C.Int64, nil
+}
+
+//newPagination 计算总页数
+func newPagination(total int64, pageNum int, pageSize int) Pagination {
+	return Pagination{
+		Total: total,
+		Page:  pageNum,
+		Size:  pageSize,
+		Pages: int((total + int64(pageSize) - 1) / int64(pageSize)),
+	}
+}
@@ -0,0 +1,277 @@
+package builder
+
+import (
+	"fmt"
+	"github.com/tangpanqing/aorm/driver"
+	"reflect"
+	"strings"
+)
+
+const (
+	partText = iota
+	partPositional
+	partEscape
+	partNamed
+)
+
+//sqlPart sql按占位符拆分后的片段,命名参数的 text 为参数名
+type sqlPart struct {
+	kind int
+	text string
+}
+
+//rawSql 带参数的原始sql,用于 WhereRaw,HavingRaw
+type rawSql struct {
+	query string
+	args  []interface{}
+}
+
+//convertPlaceholder 把 ? 与命名参数转成方言的占位符,例如Postgres的$1,Mssql的@p1,?? 转义为 ?
+func (b *Builder) convertPlaceholder(query string, args []any) (string, []any, error) {
+	namedMap, isNamed := getNamedArgs(args)
+
+	dialect := b.dialect()
+	if !isNamed && (!strings.Contains(query, "?") || (dialect.Placeholder(1) == "?" && !strings.Contains(query, "??"))) {
+		return query, args, nil
+	}
+
+	var bd strings.Builder
+	var namedArgs []any
+	t := 1
+	parts := b.splitSql(query, isNamed)
+	for i := 0; i < len(parts); i++ {
+		switch parts[i].kind {
+		case partText:
+			bd.WriteString(parts[i].text)
+		case partEscape:
+			bd.WriteString("?")
+		case partPositional:
+			if isNamed {
+				return "", args, fmt.Errorf("%w: ? can not be used with named parameters", ErrMissingParam)
+			}
+			bd.WriteString(dialect.Placeholder(t))
+			t++
+		case partNamed:
+			val, ok := namedMap[parts[i].text]
+			if !ok {
+				return "", args, fmt.Errorf("%w: %s", ErrMissingParam, parts[i].text)
+			}
+			bd.WriteString(dialect.Placeholder(t))
+			namedArgs = append(namedArgs, val)
+			t++
+		}
+	}
+
+	if isNamed {
+		return bd.String(), namedArgs, nil
+	}
+
+	return bd.String(), args, nil
+}
+
+//bindNamed 把命名参数转成 ? 占位符,?? 转义保持不变,留给执行前按方言转换
+func (b *Builder) bindNamed(query string, args []any) (string, []any, error) {
+	namedMap, isNamed := getNamedArgs(args)
+	if !isNamed {
+		return query, args, nil
+	}
+
+	var bd strings.Builder
+	var namedArgs []any
+	parts := b.splitSql(query, true)
+	for i := 0; i < len(parts); i++ {
+		switch parts[i].kind {
+		case partText:
+			bd.WriteString(parts[i].text)
+		case partEscape:
+			bd.WriteString("??")
+		case partPositional:
+			return "", args, fmt.Errorf("%w: ? can not be used with named parameters", ErrMissingParam)
+		case partNamed:
+			val, ok := namedMap[parts[i].text]
+			if !ok {
+				return "", args, fmt.Errorf("%w: %s", ErrMissingParam, parts[i].text)
+			}
+			bd.WriteString("?")
+			namedArgs = append(namedArgs, val)
+		}
+	}
+
+	return bd.String(), namedArgs, nil
+}
+
+// NamedArgs 命名参数,作为 RawSql,WhereRaw,HavingRaw 唯一的参数时,按 :name,@name 绑定,通过 Named 或 NamedStruct 创建
+type NamedArgs struct {
+	values map[string]interface{}
+}
+
+// Named 以 map 作为命名参数,例如 RawSql("SELECT * FROM person WHERE id=:id", builder.Named(map[string]interface{}{"id": 1}))
+func Named(values map[string]interface{}) NamedArgs {
+	return NamedArgs{values}
+}
+
+// NamedStruct 以结构体作为命名参数,可以用字段名或者列名
+func NamedStruct(dest interface{}) NamedArgs {
+	values := make(map[string]interface{})
+
+	valueOf := reflect.Indirect(reflect.ValueOf(dest))
+	if valueOf.Kind() != reflect.Struct {
+		return NamedArgs{values}
+	}
+
+	for i := 0; i < valueOf.NumField(); i++ {
+		if !valueOf.Type().Field(i).IsExported() {
+			continue
+		}
+		val, _ := getValueByReflect(valueOf.Field(i))
+		key, _ := getFieldNameByStructField(valueOf.Type().Field(i))
+		values[key] = val
+		values[valueOf.Type().Field(i).Name] = val
+	}
+
+	return NamedArgs{values}
+}
+
+//getNamedArgs 只有一个参数且为 NamedArgs 时作为命名参数
+func getNamedArgs(args []any) (map[string]interface{}, bool) {
+	if len(args) != 1 {
+		return nil, false
+	}
+
+	named, ok := args[0].(NamedArgs)
+	if !ok {
+		return nil, false
+	}
+
+	return named.values, true
+}
+
+//splitSql 扫描sql,跳过字符串,带引号的标识符与注释,拆分出 ? 占位符,?? 转义,withNamed 为 true 时还拆分出 :name,@name 命名参数
+func (b *Builder) splitSql(query string, withNamed bool) []sqlPart {
+	lex := driver.GetLexDialect(b.dialect()).LexSyntax()
+
+	var parts []sqlPart
+	start := 0
+	n := len(query)
+	for i := 0; i < n; {
+		c := query[i]
+		switch {
+		case c == '\'':
+			i = skipQuoted(query, i, lex.Backslash || (lex.EscapeString && isEscapeString(query, i)))
+		case c == '"':
+			i = skipQuoted(query, i, lex.Backslash)
+		case c == '`':
+			i = skipQuoted(query, i, false)
+		case c == '[' && lex.BracketQuote:
+			i = skipUntil(query, i+1, "]")
+		case c == '-' && i+1 < n && query[i+1] == '-':
+			i = skipUntil(query, i+2, "\n")
+		case c == '#' && lex.HashComment:
+			i = skipUntil(query, i+1, "\n")
+		case c == '/' && i+1 < n && query[i+1] == '*':
+			i = skipUntil(query, i+2, "*/")
+		case c == '$' && lex.DollarQuote && (i == 0 || !isIdentChar(query[i-1])):
+			i = skipDollarQuoted(query, i)
+		case c == '?':
+			if i > start {
+				parts = append(parts, sqlPart{partText, query[start:i]})
+			}
+			if i+1 < n && query[i+1] == '?' {
+				parts = append(parts, sqlPart{partEscape, "??"})
+				i += 2
+			} else {
+				parts = append(parts, sqlPart{partPositional, "?"})
+				i++
+			}
+			start = i
+		case withNamed && (c == ':' || c == '@') && isNamedStart(query, i):
+			end := i + 1
+			for end < n && isIdentChar(query[end]) {
+				end++
+			}
+			if i > start {
+				parts = append(parts, sqlPart{partText, query[start:i]})
+			}
+			parts = append(parts, sqlPart{partNamed, query[i+1 : end]})
+			i = end
+			start = i
+		default:
+			i++
+		}
+	}
+
+	if n > start {
+		parts = append(parts, sqlPart{partText, query[start:]})
+	}
+
+	return parts
+}
+
+//skipQuoted 跳过引号包围的部分,连续两个引号视为转义,backslash 为 true 时反斜杠也是转义,返回结束引号之后的位置
+func skipQuoted(query string, i int, backslash bool) int {
+	quote := query[i]
+	for j := i + 1; j < len(query); j++ {
+		if backslash && query[j] == '\\' {
+			j++
+			continue
+		}
+		if query[j] == quote {
+			if j+1 < len(query) && query[j+1] == quote {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(query)
+}
+
+//skipUntil 从 from 开始跳到 end 之后的位置,找不到时到结尾
+func skipUntil(query string, from int, end string) int {
+	if from >= len(query) {
+		return len(query)
+	}
+
+	index := strings.Index(query[from:], end)
+	if index == -1 {
+		return len(query)
+	}
+	return from + index + len(end)
+}
+
+//skipDollarQuoted 跳过例如Postgres的 $tag$...$tag$ 字符串,不是该格式时只跳过 $
+func skipDollarQuoted(query string, i int) int {
+	j := i + 1
+	for j < len(query) && isIdentChar(query[j]) {
+		j++
+	}
+	if j >= len(query) || query[j] != '$' || (j > i+1 && query[i+1] >= '0' && query[i+1] <= '9') {
+		return i + 1
+	}
+
+	return skipUntil(query, j+1, query[i:j+1])
+}
+
+//isEscapeString 是否为例如Postgres的 E'...' 字符串,其中反斜杠是转义
+func isEscapeString(query string, i int) bool {
+	if i == 0 || (query[i-1] != 'E' && query[i-1] != 'e') {
+		return false
+	}
+	return i == 1 || !isIdentChar(query[i-2])
+}
+
+//isNamedStart 是否为命名参数的开始,排除 :: 类型转换,@@ 系统变量,以及前面是标识符的情况
+func isNamedStart(query string, i int) bool {
+	if i+1 >= len(query) || !isIdentChar(query[i+1]) || (query[i+1] >= '0' && query[i+1] <= '9') {
+		return false
+	}
+	if i > 0 && (query[i-1] == query[i] || isIdentChar(query[i-1])) {
+		return false
+	}
+	return true
+}
+
+//isIdentChar 是否为标识符的字符,非ascii字符也视为标识符
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+}
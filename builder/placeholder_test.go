@@ -0,0 +1,134 @@
+package builder
+
+import (
+	"errors"
+	"github.com/tangpanqing/aorm/base"
+	"github.com/tangpanqing/aorm/driver"
+	"reflect"
+	"testing"
+)
+
+func newTestBuilder(driverName string) *Builder {
+	return &Builder{Link: &base.Db{Driver: driverName}}
+}
+
+func TestSplitSql(t *testing.T) {
+	tests := []struct {
+		driver    string
+		query     string
+		withNamed bool
+		want      []sqlPart
+	}{
+		{driver.Mysql, "a=? AND b=?", false, []sqlPart{{partText, "a="}, {partPositional, "?"}, {partText, " AND b="}, {partPositional, "?"}}},
+		{driver.Mysql, "a ?? b", false, []sqlPart{{partText, "a "}, {partEscape, "??"}, {partText, " b"}}},
+		{driver.Mysql, "`a?b`='?' AND c=?", false, []sqlPart{{partText, "`a?b`='?' AND c="}, {partPositional, "?"}}},
+		{driver.Mysql, "'it\\'s ?'=?", false, []sqlPart{{partText, "'it\\'s ?'="}, {partPositional, "?"}}},
+		{driver.Mysql, "# ?\na=?", false, []sqlPart{{partText, "# ?\na="}, {partPositional, "?"}}},
+		{driver.Mysql, "a=:id", false, []sqlPart{{partText, "a=:id"}}},
+		{driver.Mysql, "a=:id AND b=@name", true, []sqlPart{{partText, "a="}, {partNamed, "id"}, {partText, " AND b="}, {partNamed, "name"}}},
+		{driver.Mysql, "SELECT @@version, :id", true, []sqlPart{{partText, "SELECT @@version, "}, {partNamed, "id"}}},
+		{driver.Postgres, "x::int=:id", true, []sqlPart{{partText, "x::int="}, {partNamed, "id"}}},
+		{driver.Postgres, "a=:1", true, []sqlPart{{partText, "a=:1"}}},
+		{driver.Postgres, "E'it\\'s ?'=?", false, []sqlPart{{partText, "E'it\\'s ?'="}, {partPositional, "?"}}},
+		{driver.Postgres, "'a\\'=?", false, []sqlPart{{partText, "'a\\'="}, {partPositional, "?"}}},
+		{driver.Postgres, "$tag$ ? :id $tag$=?", true, []sqlPart{{partText, "$tag$ ? :id $tag$="}, {partPositional, "?"}}},
+		{driver.Postgres, "$$ ? $$=?", false, []sqlPart{{partText, "$$ ? $$="}, {partPositional, "?"}}},
+		{driver.Postgres, "a=$1 OR b=?", false, []sqlPart{{partText, "a=$1 OR b="}, {partPositional, "?"}}},
+		{driver.Postgres, "\"a?\"=? -- ?\n/* ? */", false, []sqlPart{{partText, "\"a?\"="}, {partPositional, "?"}, {partText, " -- ?\n/* ? */"}}},
+		{driver.Postgres, "# ?", false, []sqlPart{{partText, "# "}, {partPositional, "?"}}},
+		{driver.Mssql, "[a?b]=?", false, []sqlPart{{partText, "[a?b]="}, {partPositional, "?"}}},
+		{driver.Mssql, "@@ROWCOUNT=@n", true, []sqlPart{{partText, "@@ROWCOUNT="}, {partNamed, "n"}}},
+		{driver.Mysql, "[a?b]", false, []sqlPart{{partText, "[a"}, {partPositional, "?"}, {partText, "b]"}}},
+		{driver.Sqlite3, "'a''?'=?", false, []sqlPart{{partText, "'a''?'="}, {partPositional, "?"}}},
+	}
+
+	for _, tt := range tests {
+		got := newTestBuilder(tt.driver).splitSql(tt.query, tt.withNamed)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s splitSql(%q) = %v, want %v", tt.driver, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestConvertPlaceholder(t *testing.T) {
+	named := Named(map[string]interface{}{"id": 1, "name": "Alice"})
+
+	tests := []struct {
+		driver   string
+		query    string
+		args     []any
+		want     string
+		wantArgs []any
+		wantErr  error
+	}{
+		{driver.Mysql, "a=? AND b=?", []any{1, 2}, "a=? AND b=?", []any{1, 2}, nil},
+		{driver.Mysql, "a ?? b AND c=?", []any{1}, "a ? b AND c=?", []any{1}, nil},
+		{driver.Postgres, "a=? AND b=?", []any{1, 2}, "a=$1 AND b=$2", []any{1, 2}, nil},
+		{driver.Postgres, "a ?? b AND c=?", []any{1}, "a ? b AND c=$1", []any{1}, nil},
+		{driver.Postgres, "x::int=? AND y='?'", []any{1}, "x::int=$1 AND y='?'", []any{1}, nil},
+		{driver.Postgres, "$tag$?$tag$=?", []any{1}, "$tag$?$tag$=$1", []any{1}, nil},
+		{driver.Mssql, "[a?]=? AND b=?", []any{1, 2}, "[a?]=@p1 AND b=@p2", []any{1, 2}, nil},
+		{driver.Sqlite3, "a=:id", []any{named}, "a=?", []any{1}, nil},
+		{driver.Postgres, "a=:id AND b=@name AND c=:id", []any{named}, "a=$1 AND b=$2 AND c=$3", []any{1, "Alice", 1}, nil},
+		{driver.Postgres, "x::text=:name", []any{named}, "x::text=$1", []any{"Alice"}, nil},
+		{driver.Mssql, "@@ROWCOUNT>0 AND a=@id", []any{named}, "@@ROWCOUNT>0 AND a=@p1", []any{1}, nil},
+		{driver.Mysql, "a=:id ?? b", []any{named}, "a=? ? b", []any{1}, nil},
+		{driver.Mysql, "a=:age", []any{named}, "", nil, ErrMissingParam},
+		{driver.Mysql, "a=:id AND b=?", []any{named}, "", nil, ErrMissingParam},
+	}
+
+	for _, tt := range tests {
+		got, gotArgs, err := newTestBuilder(tt.driver).convertPlaceholder(tt.query, tt.args)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%s convertPlaceholder(%q) error = %v, want %v", tt.driver, tt.query, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s convertPlaceholder(%q) error = %v", tt.driver, tt.query, err)
+		}
+		if got != tt.want || !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+			t.Fatalf("%s convertPlaceholder(%q) = %q %v, want %q %v", tt.driver, tt.query, got, gotArgs, tt.want, tt.wantArgs)
+		}
+	}
+}
+
+func TestBindNamed(t *testing.T) {
+	named := Named(map[string]interface{}{"id": 1, "name": "Alice"})
+
+	tests := []struct {
+		driver   string
+		query    string
+		args     []any
+		want     string
+		wantArgs []any
+		wantErr  error
+	}{
+		{driver.Mysql, "a=? AND b=?", []any{1, 2}, "a=? AND b=?", []any{1, 2}, nil},
+		{driver.Mysql, "a=:id AND b=@name", []any{named}, "a=? AND b=?", []any{1, "Alice"}, nil},
+		{driver.Postgres, "a=:id AND b ?? c", []any{named}, "a=? AND b ?? c", []any{1}, nil},
+		{driver.Postgres, "x::int=:id AND y=':name'", []any{named}, "x::int=? AND y=':name'", []any{1}, nil},
+		{driver.Postgres, "$q$:name$q$=:id", []any{named}, "$q$:name$q$=?", []any{1}, nil},
+		{driver.Mssql, "[:name]=:id", []any{named}, "[:name]=?", []any{1}, nil},
+		{driver.Mysql, "`@name`=@id AND @@autocommit=1", []any{named}, "`@name`=? AND @@autocommit=1", []any{1}, nil},
+		{driver.Mysql, "a=:age", []any{named}, "", nil, ErrMissingParam},
+		{driver.Mysql, "a=:id AND b=?", []any{named}, "", nil, ErrMissingParam},
+	}
+
+	for _, tt := range tests {
+		got, gotArgs, err := newTestBuilder(tt.driver).bindNamed(tt.query, tt.args)
+		if tt.wantErr != nil {
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("%s bindNamed(%q) error = %v, want %v", tt.driver, tt.query, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s bindNamed(%q) error = %v", tt.driver, tt.query, err)
+		}
+		if got != tt.want || !reflect.DeepEqual(gotArgs, tt.wantArgs) {
+			t.Fatalf("%s bindNamed(%q) = %q %v, want %q %v", tt.driver, tt.query, got, gotArgs, tt.want, tt.wantArgs)
+		}
+	}
+}
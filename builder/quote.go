@@ -0,0 +1,137 @@
+package builder
+
+import (
+	"fmt"
+	"github.com/tangpanqing/aorm/base"
+	"github.com/tangpanqing/aorm/driver"
+)
+
+//dialect 获取当前连接的方言,按驱动名从注册的方言中查找
+func (b *Builder) dialect() driver.Dialect {
+	return driver.GetDialect(b.Link.DriverName())
+}
+
+//queryDialect 查询语句的写法,方言没有实现时按 Flavor 处理
+func (b *Builder) queryDialect() driver.QueryDialect {
+	return driver.GetQueryDialect(b.dialect())
+}
+
+//writeSyntax 更新与删除的写法,方言没有实现时按 Flavor 处理
+func (b *Builder) writeSyntax() driver.WriteSyntax {
+	return driver.GetWriteDialect(b.dialect()).WriteSyntax()
+}
+
+//supports 当前数据库是否支持某个特性,方言没有实现 FeatureDialect 时返回 def
+//方言实现 VersionDialect 时先获取数据库版本,连接支持时按连接池缓存
+func (b *Builder) supports(feature driver.Feature, def bool) (bool, error) {
+	dialect := b.dialect()
+	featureDialect, ok := dialect.(driver.FeatureDialect)
+	if !ok {
+		return def, nil
+	}
+
+	var version string
+	if versionDialect, isVersion := dialect.(driver.VersionDialect); isVersion {
+		versionLink, isLink := b.Link.(base.VersionLink)
+		if !isLink {
+			return featureDialect.Supports(feature, ""), nil
+		}
+
+		var err error
+		if version, err = versionLink.ServerVersion(b.Context(), versionDialect.VersionQuery()); err != nil {
+			return false, b.wrapError(err)
+		}
+	}
+
+	return featureDialect.Supports(feature, version), nil
+}
+
+//checkFeatures 执行前检查数据库是否支持用到的窗口函数,INTERSECT,EXCEPT 与 FULL OUTER JOIN,合并查询与派生表的子查询一并检查,只生成sql时不检查
+func (b *Builder) checkFeatures() error {
+	if sub, ok := b.table.(**Builder); ok {
+		if err := (*sub).checkFeatures(); err != nil {
+			return err
+		}
+	}
+
+	var list []string
+	for i := 0; i < len(b.selectList); i++ {
+		if b.selectList[i].Over != nil {
+			list = append(list, "OVER")
+			break
+		}
+	}
+	for i := 0; i < len(b.unionList); i++ {
+		if err := b.unionList[i].builder.checkFeatures(); err != nil {
+			return err
+		}
+		if b.unionList[i].unionType == "INTERSECT" || b.unionList[i].unionType == "EXCEPT" {
+			list = append(list, b.unionList[i].unionType)
+		}
+	}
+	for i := 0; i < len(b.joinList); i++ {
+		if b.joinList[i].joinType == "FULL OUTER JOIN" {
+			list = append(list, b.joinList[i].joinType)
+		}
+	}
+
+	for i := 0; i < len(list); i++ {
+		feature := driver.FeatureIntersect
+		if list[i] == "FULL OUTER JOIN" {
+			feature = driver.FeatureFullJoin
+		} else if list[i] == "OVER" {
+			feature = driver.FeatureWindow
+		}
+
+		supported, err := b.supports(feature, true)
+		if err != nil {
+			return err
+		}
+		if !supported {
+			return fmt.Errorf("%w: %s %s", ErrNotSupported, b.Link.DriverName(), list[i])
+		}
+	}
+
+	return nil
+}
+
+//quote 按方言给标识符加上引号,Mysql 使用反引号,Mssql 使用方括号,其他使用双引号
+func (b *Builder) quote(name string) string {
+	return b.dialect().Quote(name)
+}
+
+//quoteList 给多个标识符加上引号
+func (b *Builder) quoteList(names []string) []string {
+	var list []string
+	for i := 0; i < len(names); i++ {
+		list = append(list, b.quote(names[i]))
+	}
+	return list
+}
+
+//getTableName 获取加上引号的表名
+func (b *Builder) getTableName(table interface{}) (string, error) {
+	tableName, err := b.getRegistry().getTableNameByTable(table)
+	if err != nil {
+		return "", err
+	}
+	return b.quote(tableName), nil
+}
+
+//getFieldName 获取加上引号的字段名
+func (b *Builder) getFieldName(field interface{}) (string, error) {
+	fieldName, err := b.getRegistry().getFieldNameByField(field)
+	if err != nil {
+		return "", err
+	}
+	return b.quote(fieldName), nil
+}
+
+//getPrefix 获取加上引号的字段前缀,没有前缀时返回空
+func (b *Builder) getPrefix(field interface{}, prefix ...string) (string, error) {
+	str, err := b.getRegistry().getPrefixByField(field, prefix...)
+	if err != nil {
+		return "", err
+	}
+	return b.quote(str), nil
+}
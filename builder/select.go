@@ -1,7 +1,11 @@
 package builder
 
 func (b *Builder) SelectAll(table interface{}) *Builder {
-	return b.selectCommon("", "*", nil, getTableNameByTable(table))
+	tableName, err := b.getRegistry().getTableNameByTable(table)
+	if err != nil {
+		b.err = err
+	}
+	return b.selectCommon("", "*", nil, tableName)
 }
 
 // Select 链式操作-查询哪些字段,默认 *
@@ -49,7 +53,7 @@ func (b *Builder) SelectGroupConcat(field interface{}, fieldNew interface{}, pre
 }
 
 func (b *Builder) selectCommon(funcName string, field interface{}, fieldNew interface{}, prefix ...string) *Builder {
-	b.selectList = append(b.selectList, SelectItem{funcName, prefix, field, fieldNew})
+	b.selectList = append(b.selectList, SelectItem{funcName, prefix, field, fieldNew, nil})
 	return b
 }
 
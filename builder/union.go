@@ -0,0 +1,94 @@
+package builder
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const unionAlias = "aorm_union"
+
+type UnionItem struct {
+	unionType string
+	builder   *Builder
+}
+
+// Union 链式操作,合并另一个查询的结果并去重
+func (b *Builder) Union(other *Builder) *Builder {
+	return b.union("UNION", other)
+}
+
+// UnionAll 链式操作,合并另一个查询的结果,不去重
+func (b *Builder) UnionAll(other *Builder) *Builder {
+	return b.union("UNION ALL", other)
+}
+
+// Intersect 链式操作,取与另一个查询结果的交集,Mysql 8.0.31 以下不支持
+func (b *Builder) Intersect(other *Builder) *Builder {
+	return b.union("INTERSECT", other)
+}
+
+// Except 链式操作,取与另一个查询结果的差集,Mysql 8.0.31 以下不支持
+func (b *Builder) Except(other *Builder) *Builder {
+	return b.union("EXCEPT", other)
+}
+
+func (b *Builder) union(unionType string, other *Builder) *Builder {
+	b.unionList = append(b.unionList, UnionItem{unionType, other})
+	return b
+}
+
+//handleUnion 拼接SQL,合并查询,本查询的排序与分页作用于合并后的结果
+func (b *Builder) handleUnion(query string, paramList []any) (string, []any, error) {
+	for i := 0; i < len(b.unionList); i++ {
+		unionItem := b.unionList[i]
+
+		//公用表表达式只能在整个语句的开头
+		if len(unionItem.builder.withList) > 0 {
+			return "", paramList, fmt.Errorf("%w: WITH in %s query, use With on the outer query", ErrNotSupported, unionItem.unionType)
+		}
+
+		//没有分页时子查询的排序不影响结果,去掉,Mssql 的派生表中也不允许只有排序
+		sub := unionItem.builder
+		if len(sub.orderList) > 0 && sub.limitItem.pageSize == 0 {
+			noOrder := *sub
+			noOrder.orderList = nil
+			sub = &noOrder
+		}
+
+		subSql, subParamList, err := sub.GetSqlAndParams()
+		if err != nil {
+			return "", paramList, err
+		}
+
+		//子查询自带分页时,需要包一层
+		if sub.limitItem.pageSize > 0 {
+			subSql = "SELECT * FROM (" + subSql + ") " + unionAlias + "_" + strconv.Itoa(i)
+		}
+
+		query += " " + unionItem.unionType + " " + subSql
+		paramList = append(paramList, subParamList...)
+	}
+
+	if len(b.orderList) == 0 && b.limitItem.pageSize == 0 {
+		return query, paramList, nil
+	}
+
+	query = "SELECT * FROM (" + query + ") " + unionAlias
+
+	if len(b.orderList) > 0 {
+		var orderList []string
+		for i := 0; i < len(b.orderList); i++ {
+			field, err := b.getFieldName(b.orderList[i].Field)
+			if err != nil {
+				return "", paramList, err
+			}
+			orderList = append(orderList, unionAlias+"."+field+" "+b.orderList[i].OrderType)
+		}
+		query += " ORDER BY " + strings.Join(orderList, ",")
+	}
+
+	limitStr, paramList := b.handleLimit(paramList)
+
+	return query + limitStr, paramList, nil
+}
@@ -0,0 +1,257 @@
+package builder
+
+import (
+	"fmt"
+	"github.com/tangpanqing/aorm/driver"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type SetItem struct {
+	Field interface{}
+	Val   interface{}
+}
+
+// Set 链式操作,更新时设置某字段,值可以是普通值,表达式或者子查询,例如 Set(&person.Age, builder.Add(builder.Col(&person.Age), 1))
+func (b *Builder) Set(field interface{}, val interface{}) *Builder {
+	b.setList = append(b.setList, SetItem{field, val})
+	return b
+}
+
+// UpdateMap 以 map 更新记录,键为字段指针或字段名,会与 Set 设置的字段一起更新
+func (b *Builder) UpdateMap(values map[interface{}]interface{}) (int64, error) {
+	if b.table == nil {
+		return 0, ErrMissingTable
+	}
+
+	var setList []SetItem
+	for field, val := range values {
+		setList = append(setList, SetItem{field, val})
+	}
+
+	//按字段名排序,保证生成的sql一致
+	var sortErr error
+	sort.SliceStable(setList, func(i, j int) bool {
+		keyI, err := b.getRegistry().getFieldNameByField(setList[i].Field)
+		if err != nil {
+			sortErr = err
+		}
+		keyJ, err := b.getRegistry().getFieldNameByField(setList[j].Field)
+		if err != nil {
+			sortErr = err
+		}
+		return keyI < keyJ
+	})
+	if sortErr != nil {
+		return 0, sortErr
+	}
+
+	tableName, err := b.getTableName(b.table)
+	if err != nil {
+		return 0, err
+	}
+
+	return b.updateCommon(tableName, setList)
+}
+
+//getSetListByReflect 从结构体中获取需要更新的字段
+func getSetListByReflect(typeOf reflect.Type, valueOf reflect.Value) []SetItem {
+	var setList []SetItem
+	for i := 0; i < typeOf.Elem().NumField(); i++ {
+		if !typeOf.Elem().Field(i).IsExported() {
+			continue
+		}
+
+		key, tagMap := getFieldNameByStructField(typeOf.Elem().Field(i))
+		val, isNotNull := getWriteValueByReflect(valueOf.Elem().Field(i), tagMap)
+		if isNotNull {
+			setList = append(setList, SetItem{key, val})
+		}
+	}
+	return setList
+}
+
+//updateCommon 通用更新,有关联时按数据库生成 UPDATE ... JOIN 或 UPDATE ... FROM,支持排序与数量限制
+func (b *Builder) updateCommon(tableName string, setList []SetItem) (int64, error) {
+	setList = append(setList, b.setList...)
+
+	var args []any
+	withStr, args, err := b.handleWith(args)
+	if err != nil {
+		return 0, err
+	}
+
+	var query string
+	if b.isLimitWrite() {
+		if len(b.joinList) > 0 {
+			return 0, fmt.Errorf("%w: %s update with join and limit", ErrNotSupported, b.Link.DriverName())
+		}
+		query, args, err = b.getUpdateSqlWithLimit(tableName, setList, args)
+	} else if len(b.joinList) == 0 {
+		query, args, err = b.getUpdateSql(tableName, setList, args)
+	} else if b.writeSyntax().UpdateJoin == driver.WriteJoin {
+		query, args, err = b.getUpdateSqlForJoin(tableName, setList, args)
+	} else if b.writeSyntax().UpdateJoin == driver.WriteFromJoin {
+		query, args, err = b.getUpdateSqlForFromJoin(tableName, setList, args)
+	} else {
+		query, args, err = b.getUpdateSqlForFrom(tableName, setList, args)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return b.execAffected(withStr+query, args...)
+}
+
+//getUpdateSql 没有关联的更新
+func (b *Builder) getUpdateSql(tableName string, setList []SetItem, args []any) (string, []any, error) {
+	setStr, args, err := b.handleSet(setList, "", args)
+	if err != nil {
+		return "", args, err
+	}
+
+	whereStr, args, err := b.handleWhere(args, false)
+	if err != nil {
+		return "", args, err
+	}
+
+	return "UPDATE " + tableName + setStr + whereStr, args, nil
+}
+
+//getUpdateSqlWithLimit 有排序或数量限制的更新
+func (b *Builder) getUpdateSqlWithLimit(tableName string, setList []SetItem, args []any) (string, []any, error) {
+	syntax := b.writeSyntax()
+	if syntax.Limit == driver.WriteLimit {
+		query, args, err := b.getUpdateSql(tableName, setList, args)
+		if err != nil {
+			return "", args, err
+		}
+
+		limitStr, args, err := b.getWriteLimit(args)
+		if err != nil {
+			return "", args, err
+		}
+
+		return query + limitStr, args, nil
+	}
+
+	if syntax.Limit == driver.WriteTop {
+		//没有排序时使用 TOP,否则更新排序分页后的派生表
+		if len(b.orderList) == 0 {
+			topStr, args, err := b.getWriteTop(args)
+			if err != nil {
+				return "", args, err
+			}
+
+			query, args, err := b.getUpdateSql(tableName, setList, args)
+			if err != nil {
+				return "", args, err
+			}
+
+			return "UPDATE" + topStr + strings.TrimPrefix(query, "UPDATE"), args, nil
+		}
+
+		alias := b.getWriteAlias(tableName)
+		setStr, args, err := b.handleSet(setList, alias, args)
+		if err != nil {
+			return "", args, err
+		}
+
+		subSql, args, err := b.getWriteSubQuery(tableName, args)
+		if err != nil {
+			return "", args, err
+		}
+
+		return "UPDATE " + alias + setStr + " FROM (" + subSql + ") " + alias, args, nil
+	}
+
+	setStr, args, err := b.handleSet(setList, "", args)
+	if err != nil {
+		return "", args, err
+	}
+
+	rowIdStr, args, err := b.getRowIdCondition(tableName, args)
+	if err != nil {
+		return "", args, err
+	}
+
+	return "UPDATE " + b.getUpdateTable(tableName) + setStr + " WHERE " + rowIdStr, args, nil
+}
+
+//getUpdateSqlForJoin Mysql UPDATE t JOIN o ON ... SET ... WHERE ...
+func (b *Builder) getUpdateSqlForJoin(tableName string, setList []SetItem, args []any) (string, []any, error) {
+	joinStr, args, err := b.handleJoin(args)
+	if err != nil {
+		return "", args, err
+	}
+
+	setStr, args, err := b.handleSet(setList, b.getUpdatePrefix(tableName), args)
+	if err != nil {
+		return "", args, err
+	}
+
+	whereStr, args, err := b.handleWhere(args, true)
+	if err != nil {
+		return "", args, err
+	}
+
+	return "UPDATE " + b.getUpdateTable(tableName) + joinStr + setStr + whereStr, args, nil
+}
+
+//getUpdateSqlForFromJoin Mssql UPDATE a SET ... FROM t a JOIN o ON ... WHERE ...
+func (b *Builder) getUpdateSqlForFromJoin(tableName string, setList []SetItem, args []any) (string, []any, error) {
+	prefix := b.getUpdatePrefix(tableName)
+	setStr, args, err := b.handleSet(setList, prefix, args)
+	if err != nil {
+		return "", args, err
+	}
+
+	joinStr, args, err := b.handleJoin(args)
+	if err != nil {
+		return "", args, err
+	}
+
+	whereStr, args, err := b.handleWhere(args, true)
+	if err != nil {
+		return "", args, err
+	}
+
+	return "UPDATE " + prefix + setStr + " FROM " + b.getUpdateTable(tableName) + joinStr + whereStr, args, nil
+}
+
+//getUpdateSqlForFrom Postgres,Sqlite3 UPDATE t SET ... FROM o WHERE 关联条件 AND ...,只支持内联
+func (b *Builder) getUpdateSqlForFrom(tableName string, setList []SetItem, args []any) (string, []any, error) {
+	setStr, args, err := b.handleSet(setList, "", args)
+	if err != nil {
+		return "", args, err
+	}
+
+	fromStr, whereList, args, err := b.getJoinAsFrom(args)
+	if err != nil {
+		return "", args, err
+	}
+
+	query := "UPDATE " + b.getUpdateTable(tableName) + setStr + " FROM " + fromStr
+	if len(whereList) > 0 {
+		query += " WHERE " + strings.Join(whereList, " AND ")
+	}
+
+	return query, args, nil
+}
+
+//getUpdatePrefix 更新的表的前缀,有别名时使用别名
+func (b *Builder) getUpdatePrefix(tableName string) string {
+	if b.tableAlias != "" {
+		return b.quote(b.tableAlias)
+	}
+	return tableName
+}
+
+//getUpdateTable 更新的表,有别名时带上别名
+func (b *Builder) getUpdateTable(tableName string) string {
+	if b.tableAlias != "" {
+		return tableName + " " + b.quote(b.tableAlias)
+	}
+	return tableName
+}
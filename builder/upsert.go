@@ -0,0 +1,225 @@
+package builder
+
+import (
+	"fmt"
+	"github.com/tangpanqing/aorm/driver"
+	"reflect"
+	"strings"
+)
+
+// Upsert 增加一条记录,冲突时更新指定字段,updateColumns 为空时忽略该记录
+// conflictColumns 为空时使用主键, 返回影响的行数
+func (b *Builder) Upsert(dest interface{}, conflictColumns []interface{}, updateColumns []interface{}) (int64, error) {
+	if err := b.callHook(hookBeforeInsert, dest); err != nil {
+		return 0, err
+	}
+
+	typeOf := reflect.TypeOf(dest)
+	valueOf := reflect.ValueOf(dest)
+
+	count, err := b.upsertCommon(typeOf, []reflect.Value{valueOf}, conflictColumns, updateColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = b.callHook(hookAfterInsert, dest); err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+// UpsertBatch 批量增加记录,冲突时更新指定字段
+func (b *Builder) UpsertBatch(values interface{}, conflictColumns []interface{}, updateColumns []interface{}) (int64, error) {
+	valueOf := reflect.ValueOf(values).Elem()
+
+	if valueOf.Len() == 0 {
+		return 0, ErrEmptyBatch
+	}
+	typeOf := reflect.TypeOf(values).Elem().Elem()
+
+	if err := b.callHookForSlice(hookBeforeInsert, valueOf, 0); err != nil {
+		return 0, err
+	}
+
+	var rows []reflect.Value
+	for j := 0; j < valueOf.Len(); j++ {
+		rows = append(rows, valueOf.Index(j))
+	}
+
+	count, err := b.upsertCommon(typeOf, rows, conflictColumns, updateColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = b.callHookForSlice(hookAfterInsert, valueOf, 0); err != nil {
+		return count, err
+	}
+
+	return count, nil
+}
+
+func (b *Builder) upsertCommon(typeOf reflect.Type, rows []reflect.Value, conflictColumns []interface{}, updateColumns []interface{}) (int64, error) {
+	keys, args, place := getInsertValuesByReflect(typeOf, rows)
+	if len(keys) == 0 {
+		return 0, ErrNoFields
+	}
+	if !isSameColumnsByReflect(typeOf, rows) {
+		return 0, ErrMixedColumns
+	}
+	keys = b.quoteList(keys)
+
+	tableName, err := b.getTableNameCommon(typeOf, rows[0])
+	if err != nil {
+		return 0, err
+	}
+
+	conflictKeys, err := b.getColumnNames(conflictColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	//没有指定冲突字段时,使用主键,主键没有写入时(例如为零的自增主键)不能作为冲突字段
+	if len(conflictKeys) == 0 {
+		if _, primaryKey := getPrimaryKeyByReflect(typeOf.Elem()); primaryKey != "" && isInList(keys, b.quote(primaryKey)) {
+			conflictKeys = append(conflictKeys, b.quote(primaryKey))
+		}
+	}
+
+	updateKeys, err := b.getColumnNames(updateColumns)
+	if err != nil {
+		return 0, err
+	}
+
+	//Mysql 按表中的唯一索引判断冲突,不使用冲突字段
+	upsert := b.writeSyntax().Upsert
+	if upsert != driver.WriteOnDuplicate {
+		if len(conflictKeys) == 0 {
+			return 0, ErrMissingPrimaryKey
+		}
+		for i := 0; i < len(conflictKeys); i++ {
+			if !isInList(keys, conflictKeys[i]) {
+				return 0, fmt.Errorf("%w: %s", ErrMissingConflictKey, conflictKeys[i])
+			}
+		}
+	}
+
+	var query string
+	if upsert == driver.WriteMerge {
+		query = getUpsertSqlForMerge(tableName, keys, place, conflictKeys, updateKeys, b.getAutoIncrementKeysByReflect(typeOf.Elem()))
+	} else if upsert == driver.WriteOnDuplicate {
+		query = getUpsertSqlForDuplicate(tableName, keys, place, conflictKeys, updateKeys)
+	} else {
+		query = getUpsertSqlForConflict(tableName, keys, place, conflictKeys, updateKeys)
+	}
+
+	res, err := b.RawSql(query, args...).Exec()
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+//isInList 字符串是否在列表中
+func isInList(list []string, str string) bool {
+	for i := 0; i < len(list); i++ {
+		if list[i] == str {
+			return true
+		}
+	}
+	return false
+}
+
+//getColumnNames 获取字段名,支持字段指针或字符串
+func (b *Builder) getColumnNames(columns []interface{}) ([]string, error) {
+	var names []string
+	for i := 0; i < len(columns); i++ {
+		name, err := b.getFieldName(columns[i])
+		if err != nil {
+			return names, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+//getUpsertSqlForDuplicate Mysql 使用 ON DUPLICATE KEY UPDATE, 没有更新字段时更新冲突字段为自身
+func getUpsertSqlForDuplicate(tableName string, keys []string, place []string, conflictKeys []string, updateKeys []string) string {
+	var sets []string
+	for i := 0; i < len(updateKeys); i++ {
+		sets = append(sets, updateKeys[i]+"=VALUES("+updateKeys[i]+")")
+	}
+
+	if len(sets) == 0 {
+		noop := keys[0]
+		if len(conflictKeys) > 0 {
+			noop = conflictKeys[0]
+		}
+		sets = append(sets, noop+"="+noop)
+	}
+
+	return "INSERT INTO " + tableName + " (" + strings.Join(keys, ",") + ") VALUES " + strings.Join(place, ",") +
+		" ON DUPLICATE KEY UPDATE " + strings.Join(sets, ",")
+}
+
+//getUpsertSqlForConflict Postgres,Sqlite3 使用 ON CONFLICT
+func getUpsertSqlForConflict(tableName string, keys []string, place []string, conflictKeys []string, updateKeys []string) string {
+	query := "INSERT INTO " + tableName + " (" + strings.Join(keys, ",") + ") VALUES " + strings.Join(place, ",") +
+		" ON CONFLICT (" + strings.Join(conflictKeys, ",") + ")"
+
+	if len(updateKeys) == 0 {
+		return query + " DO NOTHING"
+	}
+
+	var sets []string
+	for i := 0; i < len(updateKeys); i++ {
+		sets = append(sets, updateKeys[i]+"=excluded."+updateKeys[i])
+	}
+
+	return query + " DO UPDATE SET " + strings.Join(sets, ",")
+}
+
+//getAutoIncrementKeysByReflect 获取自增字段名,已加上引号
+func (b *Builder) getAutoIncrementKeysByReflect(destType reflect.Type) map[string]bool {
+	keys := make(map[string]bool)
+	for i := 0; i < destType.NumField(); i++ {
+		key, tagMap := getFieldNameByStructField(destType.Field(i))
+		if _, ok := tagMap["auto_increment"]; ok {
+			keys[b.quote(key)] = true
+		}
+	}
+	return keys
+}
+
+//getUpsertSqlForMerge Mssql 使用 MERGE, 自增字段不能显式写入,插入时跳过
+func getUpsertSqlForMerge(tableName string, keys []string, place []string, conflictKeys []string, updateKeys []string, identityKeys map[string]bool) string {
+	var on []string
+	for i := 0; i < len(conflictKeys); i++ {
+		on = append(on, "target."+conflictKeys[i]+"=source."+conflictKeys[i])
+	}
+
+	var sets []string
+	for i := 0; i < len(updateKeys); i++ {
+		sets = append(sets, "target."+updateKeys[i]+"=source."+updateKeys[i])
+	}
+
+	var insertKeys []string
+	var sourceKeys []string
+	for i := 0; i < len(keys); i++ {
+		if identityKeys[keys[i]] {
+			continue
+		}
+		insertKeys = append(insertKeys, keys[i])
+		sourceKeys = append(sourceKeys, "source."+keys[i])
+	}
+
+	query := "MERGE INTO " + tableName + " AS target USING (VALUES " + strings.Join(place, ",") + ") AS source (" + strings.Join(keys, ",") + ")" +
+		" ON " + strings.Join(on, " AND ")
+
+	if len(sets) > 0 {
+		query += " WHEN MATCHED THEN UPDATE SET " + strings.Join(sets, ",")
+	}
+
+	return query + " WHEN NOT MATCHED THEN INSERT (" + strings.Join(insertKeys, ",") + ") VALUES (" + strings.Join(sourceKeys, ",") + ");"
+}
@@ -6,13 +6,16 @@ import "reflect"
 func (b *Builder) Value(field interface{}, dest interface{}) error {
 	b.Select(field).Limit(0, 1)
 
-	fieldName := getFieldNameByField(field)
+	fieldName, err := b.getRegistry().getFieldNameByField(field)
+	if err != nil {
+		return err
+	}
 
-	stmt, rows, errRows := b.GetRows()
+	_, rows, release, errRows := b.getRows(true)
 	if errRows != nil {
 		return errRows
 	}
-	defer stmt.Close()
+	defer release()
 	defer rows.Close()
 
 	destValue := reflect.ValueOf(dest).Elem()
@@ -46,13 +49,16 @@ func (b *Builder) Value(field interface{}, dest interface{}) error {
 // Pluck 获取某一列的值
 func (b *Builder) Pluck(field interface{}, values interface{}) error {
 	b.Select(field)
-	fieldName := getFieldNameByField(field)
+	fieldName, err := b.getRegistry().getFieldNameByField(field)
+	if err != nil {
+		return err
+	}
 
-	stmt, rows, errRows := b.GetRows()
+	_, rows, release, errRows := b.getRows(true)
 	if errRows != nil {
 		return errRows
 	}
-	defer stmt.Close()
+	defer release()
 	defer rows.Close()
 
 	destSlice := reflect.Indirect(reflect.ValueOf(values))
@@ -6,6 +6,7 @@ import (
 )
 
 // Where 链式操作,以对象作为查询条件
+// 普通类型的字段为零值时视为未设置,不会作为条件,例如 Age = 0,Flag = false,需要时请使用 WhereEq 指定字段
 func (b *Builder) Where(dest interface{}) *Builder {
 	typeOf := reflect.TypeOf(dest)
 	valueOf := reflect.ValueOf(dest)
@@ -16,10 +17,13 @@ func (b *Builder) Where(dest interface{}) *Builder {
 	}
 
 	for i := 0; i < typeOf.Elem().NumField(); i++ {
-		isNotNull := valueOf.Elem().Field(i).Field(0).Field(1).Bool()
+		if !typeOf.Elem().Field(i).IsExported() {
+			continue
+		}
+
+		val, isNotNull := getWhereValueByReflect(valueOf.Elem().Field(i))
 		if isNotNull {
 			key := utils.UnderLine(typeOf.Elem().Field(i).Name)
-			val := valueOf.Elem().Field(i).Field(0).Field(0).Interface()
 			b.whereList = append(b.whereList, WhereItem{Field: key, Opt: Eq, Val: val})
 		}
 	}
@@ -81,7 +85,11 @@ func (b *Builder) WhereNotLike(field interface{}, val interface{}, prefix ...str
 	return b.whereItemAppend(field, NotLike, val, prefix...)
 }
 
-func (b *Builder) WhereRaw(val interface{}) *Builder {
+// WhereRaw 原始条件,可以带 ? 参数,或者以 Named,NamedStruct 绑定 :name,@name 命名参数
+func (b *Builder) WhereRaw(val interface{}, args ...interface{}) *Builder {
+	if query, ok := val.(string); ok && len(args) > 0 {
+		return b.whereItemAppend("", Raw, rawSql{query, args})
+	}
 	return b.whereItemAppend("", Raw, val)
 }
 
@@ -101,7 +109,107 @@ func (b *Builder) WhereRawEq(field interface{}, val interface{}, prefix ...strin
 	return b.whereItemAppend(field, RawEq, val, prefix...)
 }
 
+func (b *Builder) WhereOrEq(field interface{}, val interface{}, prefix ...string) *Builder {
+	return b.whereOrItemAppend(field, Eq, val, prefix...)
+}
+
+func (b *Builder) WhereOrNe(field interface{}, val interface{}, prefix ...string) *Builder {
+	return b.whereOrItemAppend(field, Ne, val, prefix...)
+}
+
+func (b *Builder) WhereOrGt(field interface{}, val interface{}, prefix ...string) *Builder {
+	return b.whereOrItemAppend(field, Gt, val, prefix...)
+}
+
+func (b *Builder) WhereOrGe(field interface{}, val interface{}, prefix ...string) *Builder {
+	return b.whereOrItemAppend(field, Ge, val, prefix...)
+}
+
+func (b *Builder) WhereOrLt(field interface{}, val interface{}, prefix ...string) *Builder {
+	return b.whereOrItemAppend(field, Lt, val, prefix...)
+}
+
+func (b *Builder) WhereOrLe(field interface{}, val interface{}, prefix ...string) *Builder {
+	return b.whereOrItemAppend(field, Le, val, prefix...)
+}
+
+func (b *Builder) WhereOrIn(field interface{}, val interface{}, prefix ...string) *Builder {
+	return b.whereOrItemAppend(field, In, val, prefix...)
+}
+
+func (b *Builder) WhereOrNotIn(field interface{}, val interface{}, prefix ...string) *Builder {
+	return b.whereOrItemAppend(field, NotIn, val, prefix...)
+}
+
+func (b *Builder) WhereOrBetween(field interface{}, val interface{}, prefix ...string) *Builder {
+	return b.whereOrItemAppend(field, Between, val, prefix...)
+}
+
+func (b *Builder) WhereOrNotBetween(field interface{}, val interface{}, prefix ...string) *Builder {
+	return b.whereOrItemAppend(field, NotBetween, val, prefix...)
+}
+
+func (b *Builder) WhereOrLike(field interface{}, val interface{}, prefix ...string) *Builder {
+	return b.whereOrItemAppend(field, Like, val, prefix...)
+}
+
+func (b *Builder) WhereOrNotLike(field interface{}, val interface{}, prefix ...string) *Builder {
+	return b.whereOrItemAppend(field, NotLike, val, prefix...)
+}
+
+func (b *Builder) WhereOrRaw(val interface{}, args ...interface{}) *Builder {
+	if query, ok := val.(string); ok && len(args) > 0 {
+		return b.whereOrItemAppend("", Raw, rawSql{query, args})
+	}
+	return b.whereOrItemAppend("", Raw, val)
+}
+
+func (b *Builder) WhereOrIsNull(field interface{}, prefix ...string) *Builder {
+	return b.whereOrItemAppend(field, Raw, "IS NULL", prefix...)
+}
+
+func (b *Builder) WhereOrIsNotNull(field interface{}, prefix ...string) *Builder {
+	return b.whereOrItemAppend(field, Raw, "IS NOT NULL", prefix...)
+}
+
+// WhereGroup 链式操作,以 AND 连接一组带括号的条件
+func (b *Builder) WhereGroup(fn func(*Builder)) *Builder {
+	return b.whereGroupAppend(And, fn)
+}
+
+// OrWhereGroup 链式操作,以 OR 连接一组带括号的条件
+func (b *Builder) OrWhereGroup(fn func(*Builder)) *Builder {
+	return b.whereGroupAppend(Or, fn)
+}
+
 func (b *Builder) whereItemAppend(field interface{}, opt string, val interface{}, prefix ...string) *Builder {
-	b.whereList = append(b.whereList, WhereItem{prefix, field, opt, val})
+	b.whereList = append(b.whereList, WhereItem{prefix, field, opt, val, And})
+	return b
+}
+
+func (b *Builder) whereOrItemAppend(field interface{}, opt string, val interface{}, prefix ...string) *Builder {
+	b.whereList = append(b.whereList, WhereItem{prefix, field, opt, val, Or})
+	return b
+}
+
+func (b *Builder) whereGroupAppend(logic string, fn func(*Builder)) *Builder {
+	sub := b.newGroupBuilder()
+	fn(sub)
+	if sub.err != nil && b.err == nil {
+		b.err = sub.err
+	}
+
+	b.whereList = append(b.whereList, WhereItem{Opt: Group, Val: sub.whereList, Logic: logic})
 	return b
 }
+
+//newGroupBuilder 产生用于收集条件组的 Builder
+func (b *Builder) newGroupBuilder() *Builder {
+	return &Builder{
+		Link:       b.Link,
+		ctx:        b.ctx,
+		registry:   b.registry,
+		table:      b.table,
+		tableAlias: b.tableAlias,
+	}
+}
@@ -0,0 +1,140 @@
+package builder
+
+import (
+	"strconv"
+	"strings"
+)
+
+const RowNumber = "ROW_NUMBER"
+const Rank = "RANK"
+const DenseRank = "DENSE_RANK"
+const Lag = "LAG"
+const Lead = "LEAD"
+
+const UnboundedPreceding = "UNBOUNDED PRECEDING"
+const UnboundedFollowing = "UNBOUNDED FOLLOWING"
+const CurrentRow = "CURRENT ROW"
+
+// Preceding 窗口范围,当前行之前的 n 行
+func Preceding(n int) string {
+	return strconv.Itoa(n) + " PRECEDING"
+}
+
+// Following 窗口范围,当前行之后的 n 行
+func Following(n int) string {
+	return strconv.Itoa(n) + " FOLLOWING"
+}
+
+// Over 窗口定义,通过 NewOver 创建,传给 SelectOver
+type Over struct {
+	partitionList []GroupItem
+	orderList     []OrderItem
+	frame         string
+	offset        int
+}
+
+// NewOver 创建窗口定义,例如 builder.NewOver().PartitionBy(&person.Type).OrderBy(&person.Age, builder.Desc)
+func NewOver() *Over {
+	return &Over{}
+}
+
+// SelectOver 链式操作-窗口函数 fn(field) OVER (...) as field_new,over 为空时是 OVER (),例如 SelectOver(builder.RowNumber, nil, &vo.Rn, builder.NewOver().OrderBy(&person.Age, builder.Desc))
+func (b *Builder) SelectOver(fn string, field interface{}, fieldNew interface{}, over *Over, prefix ...string) *Builder {
+	if over == nil {
+		over = NewOver()
+	}
+	b.selectList = append(b.selectList, SelectItem{fn, prefix, field, fieldNew, over})
+	return b
+}
+
+// PartitionBy 窗口分区
+func (o *Over) PartitionBy(field interface{}, prefix ...string) *Over {
+	o.partitionList = append(o.partitionList, GroupItem{prefix, field})
+	return o
+}
+
+// OrderBy 窗口内排序,注意这里不是整个查询的排序
+func (o *Over) OrderBy(field interface{}, orderType string, prefix ...string) *Over {
+	o.orderList = append(o.orderList, OrderItem{prefix, field, orderType})
+	return o
+}
+
+// Rows 窗口范围,以行计算,例如 Rows(builder.UnboundedPreceding, builder.CurrentRow)
+func (o *Over) Rows(start string, end string) *Over {
+	o.frame = "ROWS BETWEEN " + start + " AND " + end
+	return o
+}
+
+// Range 窗口范围,以值计算
+func (o *Over) Range(start string, end string) *Over {
+	o.frame = "RANGE BETWEEN " + start + " AND " + end
+	return o
+}
+
+// Offset LAG/LEAD 的偏移行数
+func (o *Over) Offset(n int) *Over {
+	o.offset = n
+	return o
+}
+
+//handleOverWith 拼接SQL,窗口函数
+func (b *Builder) handleOverWith(selectItem SelectItem) (string, []interface{}, error) {
+	var paramList []interface{}
+	var argList []string
+	if selectItem.Field != nil && selectItem.Field != "" {
+		str, fieldParamList, err := b.getFieldSql(selectItem.Field, selectItem.Prefix...)
+		if err != nil {
+			return "", nil, err
+		}
+		argList = append(argList, str)
+		paramList = append(paramList, fieldParamList...)
+	}
+
+	over := selectItem.Over
+	if over.offset > 0 {
+		argList = append(argList, strconv.Itoa(over.offset))
+	}
+
+	var overList []string
+	if len(over.partitionList) > 0 {
+		var partitionList []string
+		for i := 0; i < len(over.partitionList); i++ {
+			str, fieldParamList, err := b.getFieldSql(over.partitionList[i].Field, over.partitionList[i].Prefix...)
+			if err != nil {
+				return "", nil, err
+			}
+			partitionList = append(partitionList, str)
+			paramList = append(paramList, fieldParamList...)
+		}
+		overList = append(overList, "PARTITION BY "+strings.Join(partitionList, ","))
+	}
+
+	if len(over.orderList) > 0 {
+		var orderList []string
+		for i := 0; i < len(over.orderList); i++ {
+			str, fieldParamList, err := b.getFieldSql(over.orderList[i].Field, over.orderList[i].Prefix...)
+			if err != nil {
+				return "", nil, err
+			}
+			orderList = append(orderList, str+" "+over.orderList[i].OrderType)
+			paramList = append(paramList, fieldParamList...)
+		}
+		overList = append(overList, "ORDER BY "+strings.Join(orderList, ","))
+	}
+
+	if over.frame != "" {
+		overList = append(overList, over.frame)
+	}
+
+	return selectItem.FuncName + "(" + strings.Join(argList, ",") + ") OVER (" + strings.Join(overList, " ") + ")", paramList, nil
+}
+
+//isSelectAlias 是否为查询字段的别名,别名在排序中不需要前缀
+func (b *Builder) isSelectAlias(field interface{}) bool {
+	for i := 0; i < len(b.selectList); i++ {
+		if b.selectList[i].FieldNew != nil && b.selectList[i].FieldNew == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package builder
+
+import (
+	"strings"
+)
+
+type WithItem struct {
+	name      string
+	anchor    **Builder
+	recursive **Builder
+}
+
+// With 链式操作,公用表表达式,例如 With("t", &sub).Table("t")
+func (b *Builder) With(name string, sub **Builder) *Builder {
+	b.withList = append(b.withList, WithItem{name, sub, nil})
+	return b
+}
+
+// WithRecursive 链式操作,递归公用表表达式,anchor 与 recursive 以 UNION ALL 连接,recursive 中可以用 name 作为表名
+func (b *Builder) WithRecursive(name string, anchor **Builder, recursive **Builder) *Builder {
+	b.withList = append(b.withList, WithItem{name, anchor, recursive})
+	return b
+}
+
+//拼接SQL,公用表表达式
+func (b *Builder) handleWith(paramList []any) (string, []any, error) {
+	if len(b.withList) == 0 {
+		return "", paramList, nil
+	}
+
+	isRecursive := false
+	var withList []string
+	for i := 0; i < len(b.withList); i++ {
+		withItem := b.withList[i]
+
+		subSql, paramList2, err := (*withItem.anchor).GetSqlAndParams()
+		if err != nil {
+			return "", paramList, err
+		}
+		paramList = append(paramList, paramList2...)
+
+		if withItem.recursive != nil {
+			isRecursive = true
+
+			recursiveSql, paramList3, err := (*withItem.recursive).GetSqlAndParams()
+			if err != nil {
+				return "", paramList, err
+			}
+			paramList = append(paramList, paramList3...)
+
+			subSql += " UNION ALL " + recursiveSql
+		}
+
+		withList = append(withList, b.quote(withItem.name)+" AS ("+subSql+")")
+	}
+
+	return b.queryDialect().With(isRecursive) + strings.Join(withList, ", ") + " ", paramList, nil
+}
@@ -0,0 +1,142 @@
+package driver
+
+import (
+	"github.com/tangpanqing/aorm/utils"
+	"strconv"
+	"strings"
+)
+
+//CommonDialect 通用方言,未注册的驱动使用,也可以嵌入到自定义方言中
+type CommonDialect struct{}
+
+func (CommonDialect) Name() string {
+	return ""
+}
+
+func (CommonDialect) Flavor() string {
+	return ""
+}
+
+func (CommonDialect) Placeholder(index int) string {
+	return "?"
+}
+
+func (CommonDialect) Limit(offset int, pageSize int) (string, []any) {
+	return " Limit ?,? ", []any{offset, pageSize}
+}
+
+func (CommonDialect) Concat(vars ...string) string {
+	return "CONCAT(" + strings.Join(vars, ",") + ")"
+}
+
+func (CommonDialect) Quote(name string) string {
+	return utils.Quote(name, `"`, `"`)
+}
+
+func (CommonDialect) InsertReturning(query string, primaryKey string) (string, bool) {
+	return query, false
+}
+
+func (CommonDialect) LockForUpdate() string {
+	return " FOR UPDATE"
+}
+
+func (CommonDialect) Truncate(tableName string) string {
+	return "TRUNCATE TABLE " + tableName
+}
+
+//MysqlDialect Mysql 方言,使用反引号
+type MysqlDialect struct {
+	CommonDialect
+}
+
+func (MysqlDialect) Name() string {
+	return Mysql
+}
+
+func (MysqlDialect) Flavor() string {
+	return Mysql
+}
+
+func (MysqlDialect) Quote(name string) string {
+	return utils.Quote(name, "`", "`")
+}
+
+//MssqlDialect Mssql 方言,使用方括号,占位符是 @p1,@p2,分页关键词是 offset...fetch next,通过 SCOPE_IDENTITY 获取自增id
+type MssqlDialect struct {
+	CommonDialect
+}
+
+func (MssqlDialect) Name() string {
+	return Mssql
+}
+
+func (MssqlDialect) Flavor() string {
+	return Mssql
+}
+
+func (MssqlDialect) Placeholder(index int) string {
+	return "@p" + strconv.Itoa(index)
+}
+
+func (MssqlDialect) Limit(offset int, pageSize int) (string, []any) {
+	return " offset ? rows fetch next ? rows only ", []any{offset, pageSize}
+}
+
+func (MssqlDialect) Quote(name string) string {
+	return utils.Quote(name, "[", "]")
+}
+
+func (MssqlDialect) InsertReturning(query string, primaryKey string) (string, bool) {
+	return query + "; SELECT SCOPE_IDENTITY()", true
+}
+
+//PostgresDialect Postgres 方言,占位符是 $1,$2,分页数量在前偏移在后,通过 RETURNING 获取自增id
+type PostgresDialect struct {
+	CommonDialect
+}
+
+func (PostgresDialect) Name() string {
+	return Postgres
+}
+
+func (PostgresDialect) Flavor() string {
+	return Postgres
+}
+
+func (PostgresDialect) Placeholder(index int) string {
+	return "$" + strconv.Itoa(index)
+}
+
+func (PostgresDialect) Limit(offset int, pageSize int) (string, []any) {
+	return " Limit ? offset ? ", []any{pageSize, offset}
+}
+
+func (PostgresDialect) Concat(vars ...string) string {
+	return strings.Join(vars, "||")
+}
+
+func (d PostgresDialect) InsertReturning(query string, primaryKey string) (string, bool) {
+	return query + " RETURNING " + d.Quote(primaryKey), true
+}
+
+//Sqlite3Dialect Sqlite3 方言,没有 TRUNCATE,使用 DELETE 代替
+type Sqlite3Dialect struct {
+	CommonDialect
+}
+
+func (Sqlite3Dialect) Name() string {
+	return Sqlite3
+}
+
+func (Sqlite3Dialect) Flavor() string {
+	return Sqlite3
+}
+
+func (Sqlite3Dialect) Concat(vars ...string) string {
+	return strings.Join(vars, "||")
+}
+
+func (Sqlite3Dialect) Truncate(tableName string) string {
+	return "DELETE FROM " + tableName
+}
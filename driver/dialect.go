@@ -0,0 +1,98 @@
+package driver
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrUnknownDialect = errors.New("aorm: unknown dialect")
+
+//Dialect 数据库方言,生成sql时与数据库相关的部分都由方言决定
+type Dialect interface {
+	//Name 方言名字,与连接的驱动名一致
+	Name() string
+	//Flavor 语法所属的内置数据库,取值为 Mysql,Mssql,Postgres,Sqlite3,接口没有覆盖到的语法按此处理
+	Flavor() string
+	//Placeholder 第 index 个参数的占位符,从1开始
+	Placeholder(index int) string
+	//Limit 分页语句与参数
+	Limit(offset int, pageSize int) (string, []any)
+	//Concat 拼接字符串
+	Concat(vars ...string) string
+	//Quote 给标识符加上引号
+	Quote(name string) string
+	//InsertReturning 改写新增语句以查询出自增id,返回 false 时使用 LastInsertId
+	InsertReturning(query string, primaryKey string) (string, bool)
+	//LockForUpdate 加锁语句
+	LockForUpdate() string
+	//Truncate 清空表语句
+	Truncate(tableName string) string
+}
+
+var dialectLock sync.RWMutex
+var dialectMap = map[string]Dialect{
+	Mysql:    MysqlDialect{},
+	Mssql:    MssqlDialect{},
+	Postgres: PostgresDialect{},
+	Sqlite3:  Sqlite3Dialect{},
+}
+
+//aliasMap 驱动别名,database/sql 的驱动名与方言名不一致时使用
+var aliasMap = map[string]string{
+	"pgx":       Postgres,
+	"sqlite":    Sqlite3,
+	"sqlserver": Mssql,
+}
+
+// Register 注册方言,name 为连接的驱动名,已存在时覆盖
+func Register(name string, dialect Dialect) {
+	dialectLock.Lock()
+	defer dialectLock.Unlock()
+
+	dialectMap[name] = dialect
+}
+
+// RegisterAlias 注册驱动别名,例如 RegisterAlias("pgx", Postgres)
+func RegisterAlias(alias string, name string) {
+	dialectLock.Lock()
+	defer dialectLock.Unlock()
+
+	aliasMap[alias] = name
+}
+
+// ResolveName 把驱动别名转成方言名字,不是别名时原样返回
+func ResolveName(name string) string {
+	dialectLock.RLock()
+	defer dialectLock.RUnlock()
+
+	if _, ok := dialectMap[name]; ok {
+		return name
+	}
+	if dialectName, ok := aliasMap[name]; ok {
+		return dialectName
+	}
+
+	return name
+}
+
+// Lookup 按驱动名或别名查找已注册的方言
+func Lookup(name string) (Dialect, bool) {
+	dialectLock.RLock()
+	defer dialectLock.RUnlock()
+
+	if dialect, ok := dialectMap[name]; ok {
+		return dialect, true
+	}
+
+	dialect, ok := dialectMap[aliasMap[name]]
+	return dialect, ok
+}
+
+// GetDialect 按驱动名获取方言,没有注册时使用通用方言
+func GetDialect(name string) Dialect {
+	if dialect, ok := Lookup(name); ok {
+		return dialect
+	}
+
+	return CommonDialect{}
+}
@@ -0,0 +1,122 @@
+package driver
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+var ErrDuplicateKey = errors.New("duplicate key")
+var ErrForeignKeyViolation = errors.New("foreign key violation")
+var ErrNotNullViolation = errors.New("not null violation")
+var ErrDeadlock = errors.New("deadlock")
+
+//ErrorDialect 可选接口,识别驱动返回的错误,返回 ErrDuplicateKey 等错误类型,无法识别时返回 nil
+//没有实现时按 Flavor 对应的内置方言处理
+type ErrorDialect interface {
+	ErrorKind(err error) error
+}
+
+// GetErrorDialect 获取方言的错误识别,没有实现时使用 Flavor 对应的内置方言
+func GetErrorDialect(dialect Dialect) ErrorDialect {
+	if d, ok := dialect.(ErrorDialect); ok {
+		return d
+	}
+	return builtinDialect(dialect.Flavor()).(ErrorDialect)
+}
+
+//各数据库的错误码,Mysql与Mssql为数字,Postgres为 SQLSTATE
+var mysqlErrorMap = map[int64]error{
+	1062: ErrDuplicateKey,
+	1451: ErrForeignKeyViolation,
+	1452: ErrForeignKeyViolation,
+	1048: ErrNotNullViolation,
+	1213: ErrDeadlock,
+}
+
+var mssqlErrorMap = map[int64]error{
+	2627: ErrDuplicateKey,
+	2601: ErrDuplicateKey,
+	547:  ErrForeignKeyViolation,
+	515:  ErrNotNullViolation,
+	1205: ErrDeadlock,
+}
+
+var postgresErrorMap = map[string]error{
+	"23505": ErrDuplicateKey,
+	"23503": ErrForeignKeyViolation,
+	"23502": ErrNotNullViolation,
+	"40P01": ErrDeadlock,
+}
+
+var sqlite3ErrorMap = map[string]error{
+	"UNIQUE constraint failed":      ErrDuplicateKey,
+	"FOREIGN KEY constraint failed": ErrForeignKeyViolation,
+	"NOT NULL constraint failed":    ErrNotNullViolation,
+}
+
+func (CommonDialect) ErrorKind(err error) error {
+	return nil
+}
+
+func (MysqlDialect) ErrorKind(err error) error {
+	return mysqlErrorMap[getErrorNumber(err)]
+}
+
+func (MssqlDialect) ErrorKind(err error) error {
+	return mssqlErrorMap[getErrorNumber(err)]
+}
+
+func (PostgresDialect) ErrorKind(err error) error {
+	return postgresErrorMap[getErrorCode(err)]
+}
+
+func (Sqlite3Dialect) ErrorKind(err error) error {
+	for msg, kind := range sqlite3ErrorMap {
+		if strings.Contains(err.Error(), msg) {
+			return kind
+		}
+	}
+	return nil
+}
+
+//getErrorNumber 获取驱动错误中的 Number 字段, mysql与mssql驱动都使用该字段
+func getErrorNumber(err error) int64 {
+	for ; err != nil; err = errors.Unwrap(err) {
+		valueOf := reflect.Indirect(reflect.ValueOf(err))
+		if valueOf.Kind() != reflect.Struct {
+			continue
+		}
+
+		field := valueOf.FieldByName("Number")
+		switch field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return field.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return int64(field.Uint())
+		}
+	}
+
+	return 0
+}
+
+//getErrorCode 获取驱动错误中的 SQLSTATE, 兼容 lib/pq 与 pgx
+func getErrorCode(err error) string {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if e, ok := err.(interface{ SQLState() string }); ok {
+			return e.SQLState()
+		}
+
+		valueOf := reflect.Indirect(reflect.ValueOf(err))
+		if valueOf.Kind() != reflect.Struct {
+			continue
+		}
+
+		field := valueOf.FieldByName("Code")
+		if field.Kind() == reflect.String {
+			return field.String()
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,112 @@
+package driver
+
+import (
+	"strconv"
+	"strings"
+)
+
+//Feature 与数据库版本相关的特性
+type Feature int
+
+const (
+	//FeatureWindow 窗口函数
+	FeatureWindow Feature = iota
+	//FeatureRowValue 行值比较,例如 (a,b) > (?,?)
+	FeatureRowValue
+	//FeatureIntersect 合并查询的 INTERSECT 与 EXCEPT
+	FeatureIntersect
+	//FeatureFullJoin FULL OUTER JOIN
+	FeatureFullJoin
+)
+
+//FeatureDialect 可选接口,方言按数据库版本判断是否支持某个特性
+//没有实现时,分页与游标不使用窗口函数与行值比较,执行前也不检查用到的窗口函数,INTERSECT,EXCEPT 与 FULL OUTER JOIN
+type FeatureDialect interface {
+	Supports(feature Feature, version string) bool
+}
+
+//VersionDialect 可选接口,查询数据库版本的语句,版本按连接池缓存,只查询一次
+//没有实现时,传给 Supports 的版本为空
+type VersionDialect interface {
+	VersionQuery() string
+}
+
+func (CommonDialect) Supports(feature Feature, version string) bool {
+	return feature == FeatureIntersect || feature == FeatureFullJoin
+}
+
+//Supports Mysql 8.0 开始支持窗口函数,8.0.31 开始支持 INTERSECT 与 EXCEPT,MariaDB 分别为 10.2 与 10.3,都不支持 FULL OUTER JOIN
+func (MysqlDialect) Supports(feature Feature, version string) bool {
+	isMariadb := strings.Contains(strings.ToLower(version), "mariadb")
+	switch feature {
+	case FeatureWindow:
+		if isMariadb {
+			return isVersionAtLeast(version, 10, 2, 0)
+		}
+		return isVersionAtLeast(version, 8, 0, 0)
+	case FeatureIntersect:
+		if isMariadb {
+			return isVersionAtLeast(version, 10, 3, 0)
+		}
+		return isVersionAtLeast(version, 8, 0, 31)
+	case FeatureRowValue:
+		return true
+	}
+	return false
+}
+
+func (MysqlDialect) VersionQuery() string {
+	return "SELECT VERSION()"
+}
+
+func (MssqlDialect) Supports(feature Feature, version string) bool {
+	return feature != FeatureRowValue
+}
+
+func (PostgresDialect) Supports(feature Feature, version string) bool {
+	return true
+}
+
+//Supports Sqlite3 3.25 开始支持窗口函数,3.15 开始支持行值比较,3.39 开始支持 FULL OUTER JOIN
+func (Sqlite3Dialect) Supports(feature Feature, version string) bool {
+	switch feature {
+	case FeatureWindow:
+		return isVersionAtLeast(version, 3, 25, 0)
+	case FeatureRowValue:
+		return isVersionAtLeast(version, 3, 15, 0)
+	case FeatureFullJoin:
+		return isVersionAtLeast(version, 3, 39, 0)
+	}
+	return true
+}
+
+func (Sqlite3Dialect) VersionQuery() string {
+	return "SELECT sqlite_version()"
+}
+
+//isVersionAtLeast 版本号是否不低于 major.minor.patch,例如 8.0.31,5.5.5-10.6.12-MariaDB,版本为空时视为最新
+func isVersionAtLeast(version string, major int, minor int, patch int) bool {
+	if version == "" {
+		return true
+	}
+
+	//MariaDB 旧的复制协议会在版本前加上 5.5.5-
+	version = strings.TrimPrefix(version, "5.5.5-")
+
+	var nums []int
+	for _, part := range strings.SplitN(strings.SplitN(version, "-", 2)[0], ".", 3) {
+		num, _ := strconv.Atoi(part)
+		nums = append(nums, num)
+	}
+	for len(nums) < 3 {
+		nums = append(nums, 0)
+	}
+
+	want := []int{major, minor, patch}
+	for i := 0; i < 3; i++ {
+		if nums[i] != want[i] {
+			return nums[i] > want[i]
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package driver
+
+import (
+	"testing"
+)
+
+func TestIsVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		version string
+		major   int
+		minor   int
+		patch   int
+		want    bool
+	}{
+		{"", 8, 0, 0, true},
+		{"8.0.31", 8, 0, 31, true},
+		{"8.0.30", 8, 0, 31, false},
+		{"8.0.32", 8, 0, 31, true},
+		{"8.1.0", 8, 0, 31, true},
+		{"5.7.44", 8, 0, 0, false},
+		{"10.0.0", 8, 0, 31, true},
+		{"8", 8, 0, 0, true},
+		{"8.0", 8, 0, 1, false},
+		{"8.0.31-log", 8, 0, 31, true},
+		{"8.0.30-0ubuntu0.22.04.1", 8, 0, 31, false},
+		{"10.6.12-MariaDB", 10, 2, 0, true},
+		{"10.1.48-MariaDB", 10, 2, 0, false},
+		{"5.5.5-10.6.12-MariaDB", 10, 3, 0, true},
+		{"5.5.5-10.2.44-MariaDB", 10, 3, 0, false},
+		{"3.39.4", 3, 39, 0, true},
+		{"3.9.0", 3, 25, 0, false},
+		{"3.25", 3, 25, 0, true},
+	}
+
+	for _, tt := range tests {
+		if got := isVersionAtLeast(tt.version, tt.major, tt.minor, tt.patch); got != tt.want {
+			t.Fatalf("isVersionAtLeast(%q, %d, %d, %d) = %v, want %v", tt.version, tt.major, tt.minor, tt.patch, got, tt.want)
+		}
+	}
+}
+
+func TestMysqlSupports(t *testing.T) {
+	tests := []struct {
+		feature Feature
+		version string
+		want    bool
+	}{
+		{FeatureWindow, "8.0.0", true},
+		{FeatureWindow, "5.7.44", false},
+		{FeatureWindow, "10.2.0-MariaDB", true},
+		{FeatureWindow, "5.5.5-10.1.48-MariaDB", false},
+		{FeatureIntersect, "8.0.31", true},
+		{FeatureIntersect, "8.0.30", false},
+		{FeatureIntersect, "10.3.0-MariaDB", true},
+		{FeatureRowValue, "5.7.44", true},
+		{FeatureFullJoin, "8.0.31", false},
+	}
+
+	for _, tt := range tests {
+		if got := (MysqlDialect{}).Supports(tt.feature, tt.version); got != tt.want {
+			t.Fatalf("Supports(%v, %q) = %v, want %v", tt.feature, tt.version, got, tt.want)
+		}
+	}
+}
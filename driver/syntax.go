@@ -0,0 +1,259 @@
+package driver
+
+const CastInt = "int"
+const CastFloat = "float"
+const CastString = "string"
+
+//QueryDialect 可选接口,查询语句中与数据库相关的写法,没有实现时按 Flavor 对应的内置方言处理
+type QueryDialect interface {
+	//CastType CAST 的目标类型,typ 取值为 CastInt,CastFloat,CastString
+	CastType(typ string) string
+	//CompareFloat 浮点数与字符串参数比较时字段的写法
+	CompareFloat(field string) string
+	//HavingAlias HAVING 中能否使用查询字段的别名,不能时展开为原表达式
+	HavingAlias() bool
+	//With WITH 关键词,recursive 为 true 时是递归查询
+	With(recursive bool) string
+}
+
+const (
+	//WriteLimit 排序与数量限制追加在语句之后,只有排序时也生效
+	WriteLimit = "LIMIT"
+	//WriteTop 没有排序时使用 TOP,否则更新或删除排序分页后的派生表
+	WriteTop = "TOP"
+	//WriteRowId 在子查询中完成关联,排序与分页,按行号更新或删除
+	WriteRowId = "ROWID"
+	//WriteJoin UPDATE t JOIN o SET ...,DELETE t FROM t JOIN o
+	WriteJoin = "JOIN"
+	//WriteFromJoin UPDATE t SET ... FROM t JOIN o
+	WriteFromJoin = "FROM JOIN"
+	//WriteFrom UPDATE t SET ... FROM o WHERE ...
+	WriteFrom = "FROM"
+	//WriteUsing DELETE FROM t USING o WHERE ...
+	WriteUsing = "USING"
+	//WriteOnDuplicate INSERT ... ON DUPLICATE KEY UPDATE
+	WriteOnDuplicate = "ON DUPLICATE"
+	//WriteMerge MERGE INTO ... USING
+	WriteMerge = "MERGE"
+	//WriteOnConflict INSERT ... ON CONFLICT
+	WriteOnConflict = "ON CONFLICT"
+)
+
+//WriteSyntax 更新与删除的排序,数量限制与关联,以及冲突时更新的写法
+type WriteSyntax struct {
+	Limit      string //WriteLimit,WriteTop,WriteRowId
+	UpdateJoin string //WriteJoin,WriteFromJoin,WriteFrom
+	DeleteJoin string //WriteJoin,WriteUsing,WriteRowId
+	Upsert     string //WriteOnDuplicate,WriteMerge,WriteOnConflict
+	RowId      string //行号字段,使用 WriteRowId 时需要
+}
+
+//WriteDialect 可选接口,没有实现时按 Flavor 对应的内置方言处理
+type WriteDialect interface {
+	WriteSyntax() WriteSyntax
+}
+
+//LexSyntax 扫描sql中的字符串,标识符与注释时,与数据库相关的规则
+type LexSyntax struct {
+	Backslash    bool //字符串中反斜杠是转义
+	HashComment  bool //# 开始的单行注释
+	BracketQuote bool //[...] 标识符
+	EscapeString bool //E'...' 字符串中反斜杠是转义
+	DollarQuote  bool //$tag$...$tag$ 字符串
+}
+
+//LexDialect 可选接口,没有实现时按 Flavor 对应的内置方言处理
+type LexDialect interface {
+	LexSyntax() LexSyntax
+}
+
+//SavepointDialect 可选接口,嵌套事务的保存点语句,没有实现时按 Flavor 对应的内置方言处理
+type SavepointDialect interface {
+	Savepoint(name string) string
+	RollbackTo(name string) string
+	//ReleaseSavepoint 返回空时不执行
+	ReleaseSavepoint(name string) string
+}
+
+//builtinDialect Flavor 对应的内置方言,不受注册的方言影响
+func builtinDialect(flavor string) Dialect {
+	switch flavor {
+	case Mysql:
+		return MysqlDialect{}
+	case Mssql:
+		return MssqlDialect{}
+	case Postgres:
+		return PostgresDialect{}
+	case Sqlite3:
+		return Sqlite3Dialect{}
+	}
+	return CommonDialect{}
+}
+
+// GetQueryDialect 获取方言的查询写法,没有实现时使用 Flavor 对应的内置方言
+func GetQueryDialect(dialect Dialect) QueryDialect {
+	if d, ok := dialect.(QueryDialect); ok {
+		return d
+	}
+	return builtinDialect(dialect.Flavor()).(QueryDialect)
+}
+
+// GetWriteDialect 获取方言的更新与删除写法,没有实现时使用 Flavor 对应的内置方言
+func GetWriteDialect(dialect Dialect) WriteDialect {
+	if d, ok := dialect.(WriteDialect); ok {
+		return d
+	}
+	return builtinDialect(dialect.Flavor()).(WriteDialect)
+}
+
+// GetLexDialect 获取方言扫描sql的规则,没有实现时使用 Flavor 对应的内置方言
+func GetLexDialect(dialect Dialect) LexDialect {
+	if d, ok := dialect.(LexDialect); ok {
+		return d
+	}
+	return builtinDialect(dialect.Flavor()).(LexDialect)
+}
+
+// GetSavepointDialect 获取方言的保存点语句,没有实现时使用 Flavor 对应的内置方言
+func GetSavepointDialect(dialect Dialect) SavepointDialect {
+	if d, ok := dialect.(SavepointDialect); ok {
+		return d
+	}
+	return builtinDialect(dialect.Flavor()).(SavepointDialect)
+}
+
+func (CommonDialect) CastType(typ string) string {
+	switch typ {
+	case CastInt:
+		return "INTEGER"
+	case CastFloat:
+		return "REAL"
+	case CastString:
+		return "TEXT"
+	}
+	return typ
+}
+
+func (CommonDialect) CompareFloat(field string) string {
+	return "CONCAT(" + field + ",'')"
+}
+
+func (CommonDialect) HavingAlias() bool {
+	return true
+}
+
+func (CommonDialect) With(recursive bool) string {
+	if recursive {
+		return "WITH RECURSIVE "
+	}
+	return "WITH "
+}
+
+func (CommonDialect) WriteSyntax() WriteSyntax {
+	return WriteSyntax{Limit: WriteRowId, UpdateJoin: WriteFrom, DeleteJoin: WriteRowId, Upsert: WriteOnConflict, RowId: "rowid"}
+}
+
+func (CommonDialect) LexSyntax() LexSyntax {
+	return LexSyntax{}
+}
+
+func (CommonDialect) Savepoint(name string) string {
+	return "SAVEPOINT " + name
+}
+
+func (CommonDialect) RollbackTo(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + name
+}
+
+func (CommonDialect) ReleaseSavepoint(name string) string {
+	return "RELEASE SAVEPOINT " + name
+}
+
+func (MysqlDialect) CastType(typ string) string {
+	switch typ {
+	case CastInt:
+		return "SIGNED"
+	case CastFloat:
+		return "DECIMAL(65,10)"
+	case CastString:
+		return "CHAR"
+	}
+	return typ
+}
+
+func (MysqlDialect) WriteSyntax() WriteSyntax {
+	return WriteSyntax{Limit: WriteLimit, UpdateJoin: WriteJoin, DeleteJoin: WriteJoin, Upsert: WriteOnDuplicate}
+}
+
+func (MysqlDialect) LexSyntax() LexSyntax {
+	return LexSyntax{Backslash: true, HashComment: true}
+}
+
+func (MssqlDialect) CastType(typ string) string {
+	switch typ {
+	case CastInt:
+		return "INTEGER"
+	case CastFloat:
+		return "FLOAT"
+	case CastString:
+		return "NVARCHAR(MAX)"
+	}
+	return typ
+}
+
+func (MssqlDialect) HavingAlias() bool {
+	return false
+}
+
+//With Mssql 没有 RECURSIVE 关键词
+func (MssqlDialect) With(recursive bool) string {
+	return "WITH "
+}
+
+func (MssqlDialect) WriteSyntax() WriteSyntax {
+	return WriteSyntax{Limit: WriteTop, UpdateJoin: WriteFromJoin, DeleteJoin: WriteJoin, Upsert: WriteMerge}
+}
+
+func (MssqlDialect) LexSyntax() LexSyntax {
+	return LexSyntax{BracketQuote: true}
+}
+
+func (MssqlDialect) Savepoint(name string) string {
+	return "SAVE TRANSACTION " + name
+}
+
+func (MssqlDialect) RollbackTo(name string) string {
+	return "ROLLBACK TRANSACTION " + name
+}
+
+//ReleaseSavepoint Mssql 没有此操作
+func (MssqlDialect) ReleaseSavepoint(name string) string {
+	return ""
+}
+
+func (PostgresDialect) CastType(typ string) string {
+	if typ == CastFloat {
+		return "DOUBLE PRECISION"
+	}
+	return CommonDialect{}.CastType(typ)
+}
+
+func (PostgresDialect) CompareFloat(field string) string {
+	return field
+}
+
+func (PostgresDialect) HavingAlias() bool {
+	return false
+}
+
+func (PostgresDialect) WriteSyntax() WriteSyntax {
+	return WriteSyntax{Limit: WriteRowId, UpdateJoin: WriteFrom, DeleteJoin: WriteUsing, Upsert: WriteOnConflict, RowId: "ctid"}
+}
+
+func (PostgresDialect) LexSyntax() LexSyntax {
+	return LexSyntax{EscapeString: true, DollarQuote: true}
+}
+
+func (Sqlite3Dialect) CompareFloat(field string) string {
+	return field
+}
@@ -1,7 +1,7 @@
 package migrate_mssql
 
 import (
-	"fmt"
+	"github.com/tangpanqing/aorm/base"
 	"github.com/tangpanqing/aorm/builder"
 	"github.com/tangpanqing/aorm/null"
 	"github.com/tangpanqing/aorm/utils"
@@ -39,12 +39,12 @@ type MigrateExecutor struct {
 //ShowCreateTable 查看创建表的ddl
 func (mm *MigrateExecutor) ShowCreateTable(tableName string) string {
 	var str string
-	mm.Builder.RawSql("show create table "+tableName).Value("Create Table", &str)
+	mm.Builder.RawSql("show create table "+quote(tableName)).Value("Create Table", &str)
 	return str
 }
 
 //MigrateCommon 迁移的主要过程
-func (mm *MigrateExecutor) MigrateCommon(tableName string, typeOf reflect.Type) error {
+func (mm *MigrateExecutor) MigrateCommon(tableName string, typeOf reflect.Type, valueOf reflect.Value) error {
 	tableFromCode := mm.getTableFromCode(tableName)
 	columnsFromCode := mm.getColumnsFromCode(typeOf)
 	indexesFromCode := mm.getIndexesFromCode(typeOf, tableFromCode)
@@ -54,18 +54,18 @@ func (mm *MigrateExecutor) MigrateCommon(tableName string, typeOf reflect.Type)
 		return dbErr
 	}
 
-	tablesFromDb := mm.getTableFromDb(dbName, tableName)
+	_, name := utils.SplitTableName(tableName)
+
+	tablesFromDb := mm.getTableFromDb(dbName, name)
 	if len(tablesFromDb) != 0 {
 		tableFromDb := tablesFromDb[0]
-		columnsFromDb := mm.getColumnsFromDb(dbName, tableName)
-		indexesFromDb := mm.getIndexesFromDb(tableName)
+		columnsFromDb := mm.getColumnsFromDb(dbName, name)
+		indexesFromDb := mm.getIndexesFromDb(name)
 
-		mm.modifyTable(tableFromCode, columnsFromCode, indexesFromCode, tableFromDb, columnsFromDb, indexesFromDb)
-	} else {
-		mm.createTable(tableFromCode, columnsFromCode, indexesFromCode)
+		return mm.modifyTable(tableFromCode, columnsFromCode, indexesFromCode, tableFromDb, columnsFromDb, indexesFromDb)
 	}
 
-	return nil
+	return mm.createTable(tableFromCode, columnsFromCode, indexesFromCode)
 }
 
 func (mm *MigrateExecutor) getTableFromCode(tableName string) Table {
@@ -78,8 +78,12 @@ func (mm *MigrateExecutor) getTableFromCode(tableName string) Table {
 func (mm *MigrateExecutor) getColumnsFromCode(typeOf reflect.Type) []Column {
 	var columnsFromCode []Column
 	for i := 0; i < typeOf.Elem().NumField(); i++ {
+		if !typeOf.Elem().Field(i).IsExported() {
+			continue
+		}
+
 		fieldName := utils.UnderLine(typeOf.Elem().Field(i).Name)
-		fieldType := typeOf.Elem().Field(i).Type.Name()
+		fieldType := utils.TypeName(typeOf.Elem().Field(i).Type)
 		fieldMap := getTagMap(typeOf.Elem().Field(i).Tag.Get("aorm"))
 		columnsFromCode = append(columnsFromCode, Column{
 			ColumnName:    null.StringFrom(fieldName),
@@ -96,6 +100,8 @@ func (mm *MigrateExecutor) getColumnsFromCode(typeOf reflect.Type) []Column {
 }
 
 func (mm *MigrateExecutor) getIndexesFromCode(typeOf reflect.Type, tableFromCode Table) []Index {
+	_, tableName := utils.SplitTableName(tableFromCode.TableName.String)
+
 	var indexesFromCode []Index
 	for i := 0; i < typeOf.Elem().NumField(); i++ {
 		fieldName := utils.UnderLine(typeOf.Elem().Field(i).Name)
@@ -115,7 +121,7 @@ func (mm *MigrateExecutor) getIndexesFromCode(typeOf reflect.Type, tableFromCode
 			indexesFromCode = append(indexesFromCode, Index{
 				NonUnique:  null.IntFrom(0),
 				ColumnName: null.StringFrom(fieldName),
-				KeyName:    null.StringFrom("idx_" + tableFromCode.TableName.String + "_" + fieldName),
+				KeyName:    null.StringFrom("idx_" + tableName + "_" + fieldName),
 			})
 		}
 
@@ -124,7 +130,7 @@ func (mm *MigrateExecutor) getIndexesFromCode(typeOf reflect.Type, tableFromCode
 			indexesFromCode = append(indexesFromCode, Index{
 				NonUnique:  null.IntFrom(1),
 				ColumnName: null.StringFrom(fieldName),
-				KeyName:    null.StringFrom("idx_" + tableFromCode.TableName.String + "_" + fieldName),
+				KeyName:    null.StringFrom("idx_" + tableName + "_" + fieldName),
 			})
 		}
 	}
@@ -200,7 +206,7 @@ func (mm *MigrateExecutor) getIndexesFromDb(tableName string) []Index {
 	return indexesFromDb
 }
 
-func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index, tableFromDb Table, columnsFromDb []Column, indexesFromDb []Index) {
+func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index, tableFromDb Table, columnsFromDb []Column, indexesFromDb []Index) error {
 	for i := 0; i < len(columnsFromCode); i++ {
 		isFind := 0
 		columnCode := columnsFromCode[i]
@@ -210,26 +216,18 @@ func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Co
 			if columnCode.ColumnName == columnDb.ColumnName {
 				isFind = 1
 				if columnCode.DataType.String != columnDb.DataType.String {
-					sql := "ALTER TABLE " + tableFromCode.TableName.String + " MODIFY " + getColumnStr(columnCode)
-					fmt.Println(sql)
-
-					_, err := mm.Builder.RawSql(sql).Exec()
-					if err != nil {
-						fmt.Println(err)
-					} else {
-						fmt.Println("修改属性:" + sql)
+					sql := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " MODIFY " + getColumnStr(columnCode)
+					if err := mm.exec(sql, "修改属性:"+sql); err != nil {
+						return err
 					}
 				}
 			}
 		}
 
 		if isFind == 0 {
-			sql := "ALTER TABLE " + tableFromCode.TableName.String + " ADD " + getColumnStr(columnCode)
-			_, err := mm.Builder.RawSql(sql).Exec()
-			if err != nil {
-				fmt.Println(err)
-			} else {
-				fmt.Println("增加属性:" + sql)
+			sql := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " ADD " + getColumnStr(columnCode)
+			if err := mm.exec(sql, "增加属性:"+sql); err != nil {
+				return err
 			}
 		}
 	}
@@ -253,30 +251,26 @@ func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Co
 				}
 
 				if !keyMatch || indexCode.NonUnique.Int64 != indexDb.NonUnique.Int64 {
-					sql := "ALTER TABLE " + tableFromCode.TableName.String + " MODIFY " + getIndexStr(indexCode)
-					_, err := mm.Builder.RawSql(sql).Exec()
-					if err != nil {
-						fmt.Println(err)
-					} else {
-						fmt.Println("修改索引:" + sql)
+					sql := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " MODIFY " + getIndexStr(indexCode)
+					if err := mm.exec(sql, "修改索引:"+sql); err != nil {
+						return err
 					}
 				}
 			}
 		}
 
 		if isFind == 0 {
-			sql := "ALTER TABLE " + tableFromCode.TableName.String + " ADD " + getIndexStr(indexCode)
-			_, err := mm.Builder.RawSql(sql).Exec()
-			if err != nil {
-				fmt.Println(err)
-			} else {
-				fmt.Println("增加索引:" + sql)
+			sql := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " ADD " + getIndexStr(indexCode)
+			if err := mm.exec(sql, "增加索引:"+sql); err != nil {
+				return err
 			}
 		}
 	}
+
+	return nil
 }
 
-func (mm *MigrateExecutor) createTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index) {
+func (mm *MigrateExecutor) createTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index) error {
 	var fieldArr []string
 
 	for i := 0; i < len(columnsFromCode); i++ {
@@ -289,14 +283,36 @@ func (mm *MigrateExecutor) createTable(tableFromCode Table, columnsFromCode []Co
 		fieldArr = append(fieldArr, getIndexStr(index))
 	}
 
-	sqlStr := "CREATE TABLE " + tableFromCode.TableName.String + " (\n" + strings.Join(fieldArr, ",\n") + "\n) " + ";"
+	sqlStr := "CREATE TABLE " + quote(tableFromCode.TableName.String) + " (\n" + strings.Join(fieldArr, ",\n") + "\n) " + ";"
+
+	return mm.exec(sqlStr, "创建表:"+tableFromCode.TableName.String)
+}
 
-	_, err := mm.Builder.RawSql(sqlStr).Exec()
+//exec 执行迁移语句,成功时记录 msg,失败时记录并返回错误
+func (mm *MigrateExecutor) exec(query string, msg string) error {
+	_, err := mm.Builder.RawSql(query).Exec()
 	if err != nil {
-		fmt.Println(err)
-	} else {
-		fmt.Println("创建表:" + tableFromCode.TableName.String)
+		mm.logError(err)
+		return err
 	}
+
+	mm.logInfo(msg)
+	return nil
+}
+
+//logError 记录迁移中的错误
+func (mm *MigrateExecutor) logError(err error) {
+	mm.Builder.Logger().Log(mm.Builder.Context(), base.LogError, err.Error())
+}
+
+//logInfo 记录迁移中的信息
+func (mm *MigrateExecutor) logInfo(msg string) {
+	mm.Builder.Logger().Log(mm.Builder.Context(), base.LogInfo, msg)
+}
+
+//quote 给标识符加上方括号
+func quote(name string) string {
+	return utils.Quote(name, "[", "]")
 }
 
 func getTagMap(fieldTag string) map[string]string {
@@ -316,7 +332,7 @@ func getTagMap(fieldTag string) map[string]string {
 
 func getColumnStr(column Column) string {
 	var strArr []string
-	strArr = append(strArr, column.ColumnName.String)
+	strArr = append(strArr, quote(column.ColumnName.String))
 	if column.MaxLength.Int64 == 0 {
 		if column.DataType.String == "varchar" {
 			strArr = append(strArr, column.DataType.String+"(255)")
@@ -355,16 +371,16 @@ func getIndexStr(index Index) string {
 	if "PRIMARY" == index.KeyName.String {
 		strArr = append(strArr, index.KeyName.String)
 		strArr = append(strArr, "KEY")
-		strArr = append(strArr, "("+index.ColumnName.String+")")
+		strArr = append(strArr, "("+quote(index.ColumnName.String)+")")
 	} else {
 		if 0 == index.NonUnique.Int64 {
 			strArr = append(strArr, "Unique")
-			strArr = append(strArr, index.KeyName.String)
-			strArr = append(strArr, "("+index.ColumnName.String+")")
+			strArr = append(strArr, quote(index.KeyName.String))
+			strArr = append(strArr, "("+quote(index.ColumnName.String)+")")
 		} else {
 			strArr = append(strArr, "Index")
-			strArr = append(strArr, index.KeyName.String)
-			strArr = append(strArr, "("+index.ColumnName.String+")")
+			strArr = append(strArr, quote(index.KeyName.String))
+			strArr = append(strArr, "("+quote(index.ColumnName.String)+")")
 		}
 	}
 
@@ -1,7 +1,7 @@
 package migrate_mysql
 
 import (
-	"fmt"
+	"github.com/tangpanqing/aorm/base"
 	"github.com/tangpanqing/aorm/builder"
 	"github.com/tangpanqing/aorm/null"
 	"github.com/tangpanqing/aorm/utils"
@@ -41,7 +41,7 @@ type MigrateExecutor struct {
 //ShowCreateTable 查看创建表的ddl
 func (mm *MigrateExecutor) ShowCreateTable(tableName string) string {
 	var str string
-	mm.Builder.RawSql("show create table "+tableName).Value("Create Table", &str)
+	mm.Builder.RawSql("show create table "+quote(tableName)).Value("Create Table", &str)
 	return str
 }
 
@@ -56,18 +56,22 @@ func (mm *MigrateExecutor) MigrateCommon(tableName string, typeOf reflect.Type,
 		return dbErr
 	}
 
-	tablesFromDb := mm.getTableFromDb(dbName, tableName)
+	//表名带有库名时,从该库中查询
+	schemaName, name := utils.SplitTableName(tableName)
+	if schemaName != "" {
+		dbName = schemaName
+	}
+
+	tablesFromDb := mm.getTableFromDb(dbName, name)
 	if len(tablesFromDb) != 0 {
 		tableFromDb := tablesFromDb[0]
-		columnsFromDb := mm.getColumnsFromDb(dbName, tableName)
+		columnsFromDb := mm.getColumnsFromDb(dbName, name)
 		indexesFromDb := mm.getIndexesFromDb(tableName)
 
-		mm.modifyTable(tableFromCode, columnsFromCode, indexesFromCode, tableFromDb, columnsFromDb, indexesFromDb)
-	} else {
-		mm.createTable(tableFromCode, columnsFromCode, indexesFromCode)
+		return mm.modifyTable(tableFromCode, columnsFromCode, indexesFromCode, tableFromDb, columnsFromDb, indexesFromDb)
 	}
 
-	return nil
+	return mm.createTable(tableFromCode, columnsFromCode, indexesFromCode)
 }
 
 func (mm *MigrateExecutor) getTableFromCode(tableName string, typeOf reflect.Type, valueOf reflect.Value) Table {
@@ -96,8 +100,12 @@ func (mm *MigrateExecutor) getTableFromCode(tableName string, typeOf reflect.Typ
 func (mm *MigrateExecutor) getColumnsFromCode(typeOf reflect.Type) []Column {
 	var columnsFromCode []Column
 	for i := 0; i < typeOf.Elem().NumField(); i++ {
+		if !typeOf.Elem().Field(i).IsExported() {
+			continue
+		}
+
 		fieldName := utils.UnderLine(typeOf.Elem().Field(i).Name)
-		fieldType := typeOf.Elem().Field(i).Type.Name()
+		fieldType := utils.TypeName(typeOf.Elem().Field(i).Type)
 		fieldMap := getTagMap(typeOf.Elem().Field(i).Tag.Get("aorm"))
 
 		//如果tag里重新设置了字段名
@@ -120,6 +128,8 @@ func (mm *MigrateExecutor) getColumnsFromCode(typeOf reflect.Type) []Column {
 }
 
 func (mm *MigrateExecutor) getIndexesFromCode(typeOf reflect.Type, tableFromCode Table) []Index {
+	_, tableName := utils.SplitTableName(tableFromCode.TableName.String)
+
 	var indexesFromCode []Index
 	for i := 0; i < typeOf.Elem().NumField(); i++ {
 		fieldName := utils.UnderLine(typeOf.Elem().Field(i).Name)
@@ -139,7 +149,7 @@ func (mm *MigrateExecutor) getIndexesFromCode(typeOf reflect.Type, tableFromCode
 			indexesFromCode = append(indexesFromCode, Index{
 				NonUnique:  null.IntFrom(0),
 				ColumnName: null.StringFrom(fieldName),
-				KeyName:    null.StringFrom("idx_" + tableFromCode.TableName.String + "_" + fieldName),
+				KeyName:    null.StringFrom("idx_" + tableName + "_" + fieldName),
 			})
 		}
 
@@ -148,7 +158,7 @@ func (mm *MigrateExecutor) getIndexesFromCode(typeOf reflect.Type, tableFromCode
 			indexesFromCode = append(indexesFromCode, Index{
 				NonUnique:  null.IntFrom(1),
 				ColumnName: null.StringFrom(fieldName),
-				KeyName:    null.StringFrom("idx_" + tableFromCode.TableName.String + "_" + fieldName),
+				KeyName:    null.StringFrom("idx_" + tableName + "_" + fieldName),
 			})
 		}
 	}
@@ -194,7 +204,7 @@ func (mm *MigrateExecutor) getColumnsFromDb(dbName string, tableName string) []C
 }
 
 func (mm *MigrateExecutor) getIndexesFromDb(tableName string) []Index {
-	sqlIndex := "SHOW INDEXES FROM " + tableName
+	sqlIndex := "SHOW INDEXES FROM " + quote(tableName)
 
 	var indexsFromDb []Index
 	mm.Builder.RawSql(sqlIndex).GetMany(&indexsFromDb)
@@ -202,13 +212,17 @@ func (mm *MigrateExecutor) getIndexesFromDb(tableName string) []Index {
 	return indexsFromDb
 }
 
-func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index, tableFromDb Table, columnsFromDb []Column, indexesFromDb []Index) {
+func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index, tableFromDb Table, columnsFromDb []Column, indexesFromDb []Index) error {
 	if tableFromCode.Engine != tableFromDb.Engine {
-		mm.modifyTableEngine(tableFromCode)
+		if err := mm.modifyTableEngine(tableFromCode); err != nil {
+			return err
+		}
 	}
 
 	if tableFromCode.TableComment != tableFromDb.TableComment {
-		mm.modifyTableComment(tableFromCode)
+		if err := mm.modifyTableComment(tableFromCode); err != nil {
+			return err
+		}
 	}
 
 	for i := 0; i < len(columnsFromCode); i++ {
@@ -224,24 +238,18 @@ func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Co
 					columnCode.ColumnComment.String != columnDb.ColumnComment.String ||
 					columnCode.Extra.String != columnDb.Extra.String ||
 					columnCode.ColumnDefault.String != columnDb.ColumnDefault.String {
-					sql := "ALTER TABLE " + tableFromCode.TableName.String + " MODIFY " + getColumnStr(columnCode)
-					_, err := mm.Builder.RawSql(sql).Exec()
-					if err != nil {
-						fmt.Println(err)
-					} else {
-						fmt.Println("修改属性:" + sql)
+					sql := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " MODIFY " + getColumnStr(columnCode)
+					if err := mm.exec(sql, "修改属性:"+sql); err != nil {
+						return err
 					}
 				}
 			}
 		}
 
 		if isFind == 0 {
-			sql := "ALTER TABLE " + tableFromCode.TableName.String + " ADD " + getColumnStr(columnCode)
-			_, err := mm.Builder.RawSql(sql).Exec()
-			if err != nil {
-				fmt.Println(err)
-			} else {
-				fmt.Println("增加属性:" + sql)
+			sql := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " ADD " + getColumnStr(columnCode)
+			if err := mm.exec(sql, "增加属性:"+sql); err != nil {
+				return err
 			}
 		}
 	}
@@ -255,50 +263,36 @@ func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Co
 			if indexCode.ColumnName == indexDb.ColumnName {
 				isFind = 1
 				if indexCode.KeyName != indexDb.KeyName || indexCode.NonUnique != indexDb.NonUnique {
-					sql := "ALTER TABLE " + tableFromCode.TableName.String + " MODIFY " + getIndexStr(indexCode)
-					_, err := mm.Builder.RawSql(sql).Exec()
-					if err != nil {
-						fmt.Println(err)
-					} else {
-						fmt.Println("修改索引:" + sql)
+					sql := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " MODIFY " + getIndexStr(indexCode)
+					if err := mm.exec(sql, "修改索引:"+sql); err != nil {
+						return err
 					}
 				}
 			}
 		}
 
 		if isFind == 0 {
-			sql := "ALTER TABLE " + tableFromCode.TableName.String + " ADD " + getIndexStr(indexCode)
-			_, err := mm.Builder.RawSql(sql).Exec()
-			if err != nil {
-				fmt.Println(err)
-			} else {
-				fmt.Println("增加索引:" + sql)
+			sql := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " ADD " + getIndexStr(indexCode)
+			if err := mm.exec(sql, "增加索引:"+sql); err != nil {
+				return err
 			}
 		}
 	}
+
+	return nil
 }
 
-func (mm *MigrateExecutor) modifyTableEngine(tableFromCode Table) {
-	sql := "ALTER TABLE " + tableFromCode.TableName.String + " Engine " + tableFromCode.Engine.String
-	_, err := mm.Builder.RawSql(sql).Exec()
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		fmt.Println("修改表:" + sql)
-	}
+func (mm *MigrateExecutor) modifyTableEngine(tableFromCode Table) error {
+	sql := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " Engine " + tableFromCode.Engine.String
+	return mm.exec(sql, "修改表:"+sql)
 }
 
-func (mm *MigrateExecutor) modifyTableComment(tableFromCode Table) {
-	sql := "ALTER TABLE " + tableFromCode.TableName.String + " Comment " + tableFromCode.TableComment.String
-	_, err := mm.Builder.RawSql(sql).Exec()
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		fmt.Println("修改表:" + sql)
-	}
+func (mm *MigrateExecutor) modifyTableComment(tableFromCode Table) error {
+	sql := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " Comment " + tableFromCode.TableComment.String
+	return mm.exec(sql, "修改表:"+sql)
 }
 
-func (mm *MigrateExecutor) createTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index) {
+func (mm *MigrateExecutor) createTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index) error {
 	var fieldArr []string
 
 	for i := 0; i < len(columnsFromCode); i++ {
@@ -311,13 +305,35 @@ func (mm *MigrateExecutor) createTable(tableFromCode Table, columnsFromCode []Co
 		fieldArr = append(fieldArr, getIndexStr(index))
 	}
 
-	sql := "CREATE TABLE `" + tableFromCode.TableName.String + "` (\n" + strings.Join(fieldArr, ",\n") + "\n) " + " ENGINE " + tableFromCode.Engine.String + " COMMENT  " + tableFromCode.TableComment.String + ";"
-	_, err := mm.Builder.RawSql(sql).Exec()
+	sql := "CREATE TABLE " + quote(tableFromCode.TableName.String) + " (\n" + strings.Join(fieldArr, ",\n") + "\n) " + " ENGINE " + tableFromCode.Engine.String + " COMMENT  " + tableFromCode.TableComment.String + ";"
+	return mm.exec(sql, "创建表:"+tableFromCode.TableName.String)
+}
+
+//exec 执行迁移语句,成功时记录 msg,失败时记录并返回错误
+func (mm *MigrateExecutor) exec(query string, msg string) error {
+	_, err := mm.Builder.RawSql(query).Exec()
 	if err != nil {
-		fmt.Println(err)
-	} else {
-		fmt.Println("创建表:" + tableFromCode.TableName.String)
+		mm.logError(err)
+		return err
 	}
+
+	mm.logInfo(msg)
+	return nil
+}
+
+//logError 记录迁移中的错误
+func (mm *MigrateExecutor) logError(err error) {
+	mm.Builder.Logger().Log(mm.Builder.Context(), base.LogError, err.Error())
+}
+
+//logInfo 记录迁移中的信息
+func (mm *MigrateExecutor) logInfo(msg string) {
+	mm.Builder.Logger().Log(mm.Builder.Context(), base.LogInfo, msg)
+}
+
+//quote 给标识符加上反引号
+func quote(name string) string {
+	return utils.Quote(name, "`", "`")
 }
 
 func getTagMap(fieldTag string) map[string]string {
@@ -337,7 +353,7 @@ func getTagMap(fieldTag string) map[string]string {
 
 func getColumnStr(column Column) string {
 	var strArr []string
-	strArr = append(strArr, column.ColumnName.String)
+	strArr = append(strArr, quote(column.ColumnName.String))
 	if column.MaxLength.Int64 == 0 {
 		if column.DataType.String == "varchar" {
 			strArr = append(strArr, column.DataType.String+"(255)")
@@ -373,16 +389,16 @@ func getIndexStr(index Index) string {
 	if "PRIMARY" == index.KeyName.String {
 		strArr = append(strArr, index.KeyName.String)
 		strArr = append(strArr, "KEY")
-		strArr = append(strArr, "(`"+index.ColumnName.String+"`)")
+		strArr = append(strArr, "("+quote(index.ColumnName.String)+")")
 	} else {
 		if 0 == index.NonUnique.Int64 {
 			strArr = append(strArr, "Unique")
-			strArr = append(strArr, index.KeyName.String)
-			strArr = append(strArr, "(`"+index.ColumnName.String+"`)")
+			strArr = append(strArr, quote(index.KeyName.String))
+			strArr = append(strArr, "("+quote(index.ColumnName.String)+")")
 		} else {
 			strArr = append(strArr, "Index")
-			strArr = append(strArr, index.KeyName.String)
-			strArr = append(strArr, "(`"+index.ColumnName.String+"`)")
+			strArr = append(strArr, quote(index.KeyName.String))
+			strArr = append(strArr, "("+quote(index.ColumnName.String)+")")
 		}
 	}
 
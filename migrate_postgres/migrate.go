@@ -1,7 +1,7 @@
 package migrate_postgres
 
 import (
-	"fmt"
+	"github.com/tangpanqing/aorm/base"
 	"github.com/tangpanqing/aorm/builder"
 	"github.com/tangpanqing/aorm/null"
 	"github.com/tangpanqing/aorm/utils"
@@ -49,7 +49,7 @@ type MigrateExecutor struct {
 //ShowCreateTable 查看创建表的ddl
 func (mm *MigrateExecutor) ShowCreateTable(tableName string) string {
 	var str string
-	mm.Builder.RawSql("show create table "+tableName).Value("Create Table", &str)
+	mm.Builder.RawSql("show create table "+quote(tableName)).Value("Create Table", &str)
 	return str
 }
 
@@ -59,23 +59,26 @@ func (mm *MigrateExecutor) MigrateCommon(tableName string, typeOf reflect.Type,
 	columnsFromCode := mm.getColumnsFromCode(typeOf)
 	indexesFromCode := mm.getIndexesFromCode(typeOf, tableFromCode)
 
-	dbName, dbErr := mm.getDbName()
-	if dbErr != nil {
+	if _, dbErr := mm.getDbName(); dbErr != nil {
 		return dbErr
 	}
 
-	tablesFromDb := mm.getTableFromDb(dbName, tableName)
+	//表名没有带模式时,使用 public
+	schemaName, name := utils.SplitTableName(tableName)
+	if schemaName == "" {
+		schemaName = "public"
+	}
+
+	tablesFromDb := mm.getTableFromDb(schemaName, name)
 	if len(tablesFromDb) != 0 {
 		tableFromDb := tablesFromDb[0]
-		columnsFromDb := mm.getColumnsFromDb(dbName, tableName)
-		indexesFromDb := mm.getIndexesFromDb(tableName)
+		columnsFromDb := mm.getColumnsFromDb(schemaName, name)
+		indexesFromDb := mm.getIndexesFromDb(schemaName, name)
 
-		mm.modifyTable(tableFromCode, columnsFromCode, indexesFromCode, tableFromDb, columnsFromDb, indexesFromDb)
-	} else {
-		mm.createTable(tableFromCode, columnsFromCode, indexesFromCode)
+		return mm.modifyTable(tableFromCode, columnsFromCode, indexesFromCode, tableFromDb, columnsFromDb, indexesFromDb)
 	}
 
-	return nil
+	return mm.createTable(tableFromCode, columnsFromCode, indexesFromCode)
 }
 
 func (mm *MigrateExecutor) getTableFromCode(tableName string, typeOf reflect.Type, valueOf reflect.Value) Table {
@@ -102,8 +105,12 @@ func (mm *MigrateExecutor) getTableFromCode(tableName string, typeOf reflect.Typ
 func (mm *MigrateExecutor) getColumnsFromCode(typeOf reflect.Type) []Column {
 	var columnsFromCode []Column
 	for i := 0; i < typeOf.Elem().NumField(); i++ {
+		if !typeOf.Elem().Field(i).IsExported() {
+			continue
+		}
+
 		fieldName := utils.UnderLine(typeOf.Elem().Field(i).Name)
-		fieldType := typeOf.Elem().Field(i).Type.Name()
+		fieldType := utils.TypeName(typeOf.Elem().Field(i).Type)
 		fieldMap := getTagMap(typeOf.Elem().Field(i).Tag.Get("aorm"))
 		columnsFromCode = append(columnsFromCode, Column{
 			ColumnName:    null.StringFrom(fieldName),
@@ -120,6 +127,8 @@ func (mm *MigrateExecutor) getColumnsFromCode(typeOf reflect.Type) []Column {
 }
 
 func (mm *MigrateExecutor) getIndexesFromCode(typeOf reflect.Type, tableFromCode Table) []Index {
+	_, tableName := utils.SplitTableName(tableFromCode.TableName.String)
+
 	var indexesFromCode []Index
 	for i := 0; i < typeOf.Elem().NumField(); i++ {
 		fieldName := utils.UnderLine(typeOf.Elem().Field(i).Name)
@@ -139,7 +148,7 @@ func (mm *MigrateExecutor) getIndexesFromCode(typeOf reflect.Type, tableFromCode
 			indexesFromCode = append(indexesFromCode, Index{
 				NonUnique:  null.IntFrom(0),
 				ColumnName: null.StringFrom(fieldName),
-				KeyName:    null.StringFrom("idx_" + tableFromCode.TableName.String + "_" + fieldName),
+				KeyName:    null.StringFrom("idx_" + tableName + "_" + fieldName),
 			})
 		}
 
@@ -148,7 +157,7 @@ func (mm *MigrateExecutor) getIndexesFromCode(typeOf reflect.Type, tableFromCode
 			indexesFromCode = append(indexesFromCode, Index{
 				NonUnique:  null.IntFrom(1),
 				ColumnName: null.StringFrom(fieldName),
-				KeyName:    null.StringFrom("idx_" + tableFromCode.TableName.String + "_" + fieldName),
+				KeyName:    null.StringFrom("idx_" + tableName + "_" + fieldName),
 			})
 		}
 	}
@@ -167,8 +176,8 @@ func (mm *MigrateExecutor) getDbName() (string, error) {
 	return dbName, nil
 }
 
-func (mm *MigrateExecutor) getTableFromDb(dbName string, tableName string) []Table {
-	sql := "select a.relname as TABLE_NAME, b.description as TABLE_COMMENT from pg_class a left join (select * from pg_description where objsubid =0) b on a.oid = b.objoid where a.relname in (select tablename from pg_tables where schemaname = 'public' and tablename = " + "'" + tableName + "') order by a.relname asc"
+func (mm *MigrateExecutor) getTableFromDb(schemaName string, tableName string) []Table {
+	sql := "select a.relname as TABLE_NAME, b.description as TABLE_COMMENT from pg_class a left join (select * from pg_description where objsubid =0) b on a.oid = b.objoid where a.relname in (select tablename from pg_tables where schemaname = " + "'" + schemaName + "' and tablename = " + "'" + tableName + "') order by a.relname asc"
 	var dataList []Table
 	mm.Builder.RawSql(sql).GetMany(&dataList)
 	for i := 0; i < len(dataList); i++ {
@@ -178,10 +187,10 @@ func (mm *MigrateExecutor) getTableFromDb(dbName string, tableName string) []Tab
 	return dataList
 }
 
-func (mm *MigrateExecutor) getColumnsFromDb(dbName string, tableName string) []Column {
+func (mm *MigrateExecutor) getColumnsFromDb(schemaName string, tableName string) []Column {
 	var columnsFromDb []Column
 
-	sqlColumn := "select column_name,data_type,character_maximum_length as max_length,column_default,'' as COLUMN_COMMENT, is_nullable from information_schema.columns where table_schema='public' and table_name=" + "'" + tableName + "'"
+	sqlColumn := "select column_name,data_type,character_maximum_length as max_length,column_default,'' as COLUMN_COMMENT, is_nullable from information_schema.columns where table_schema=" + "'" + schemaName + "' and table_name=" + "'" + tableName + "'"
 
 	mm.Builder.RawSql(sqlColumn).GetMany(&columnsFromDb)
 
@@ -202,8 +211,8 @@ func (mm *MigrateExecutor) getColumnsFromDb(dbName string, tableName string) []C
 	return columnsFromDb
 }
 
-func (mm *MigrateExecutor) getIndexesFromDb(tableName string) []Index {
-	sqlIndex := "select * from pg_indexes where tablename=" + "'" + tableName + "'"
+func (mm *MigrateExecutor) getIndexesFromDb(schemaName string, tableName string) []Index {
+	sqlIndex := "select * from pg_indexes where schemaname=" + "'" + schemaName + "' and tablename=" + "'" + tableName + "'"
 	var sqliteMasterList []PgIndexes
 	mm.Builder.RawSql(sqlIndex).GetMany(&sqliteMasterList)
 
@@ -224,14 +233,14 @@ func (mm *MigrateExecutor) getIndexesFromDb(tableName string) []Index {
 		if indexName == tableName+"_pkey" {
 			indexesFromDb = append(indexesFromDb, Index{
 				NonUnique:  null.IntFrom(int64(t)),
-				ColumnName: null.StringFrom(matchArr[0][2]),
+				ColumnName: null.StringFrom(utils.Unquote(matchArr[0][2])),
 				KeyName:    null.StringFrom("PRIMARY"),
 			})
 		} else {
 			indexesFromDb = append(indexesFromDb, Index{
 				NonUnique:  null.IntFrom(int64(t)),
-				ColumnName: null.StringFrom(matchArr[0][2]),
-				KeyName:    null.StringFrom(matchArr[0][1]),
+				ColumnName: null.StringFrom(utils.Unquote(matchArr[0][2])),
+				KeyName:    null.StringFrom(utils.Unquote(matchArr[0][1])),
 			})
 		}
 	}
@@ -239,7 +248,7 @@ func (mm *MigrateExecutor) getIndexesFromDb(tableName string) []Index {
 	return indexesFromDb
 }
 
-func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index, tableFromDb Table, columnsFromDb []Column, indexesFromDb []Index) {
+func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index, tableFromDb Table, columnsFromDb []Column, indexesFromDb []Index) error {
 	//if tableFromCode.TableComment != tableFromDb.TableComment {
 	//	mm.modifyTableComment(tableFromCode)
 	//}
@@ -253,28 +262,20 @@ func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Co
 			if columnCode.ColumnName.String == columnDb.ColumnName.String {
 				isFind = 1
 				if columnCode.DataType.String != columnDb.DataType.String {
-					fmt.Println(columnCode.ColumnName.String, columnCode.DataType.String, columnDb.DataType.String)
-
-					sql := "ALTER TABLE " + tableFromCode.TableName.String + " alter COLUMN " + getColumnStr(columnCode, "driver")
+					sql := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " alter COLUMN " + getColumnStr(columnCode, "driver")
 					//fmt.Println(base)
 
-					_, err := mm.Builder.RawSql(sql).Exec()
-					if err != nil {
-						fmt.Println(err)
-					} else {
-						fmt.Println("修改属性:" + sql)
+					if err := mm.exec(sql, "修改属性:"+sql); err != nil {
+						return err
 					}
 				}
 			}
 		}
 
 		if isFind == 0 {
-			sql := "ALTER TABLE " + tableFromCode.TableName.String + " ADD " + getColumnStr(columnCode, "")
-			_, err := mm.Builder.RawSql(sql).Exec()
-			if err != nil {
-				fmt.Println(err)
-			} else {
-				fmt.Println("增加属性:" + sql)
+			sql := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " ADD " + getColumnStr(columnCode, "")
+			if err := mm.exec(sql, "增加属性:"+sql); err != nil {
+				return err
 			}
 		}
 	}
@@ -288,34 +289,30 @@ func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Co
 			if indexCode.ColumnName == indexDb.ColumnName {
 				isFind = 1
 				if indexCode.KeyName != indexDb.KeyName || indexCode.NonUnique != indexDb.NonUnique {
-					sql := "ALTER TABLE " + tableFromCode.TableName.String + " MODIFY " + getIndexStr(indexCode)
-					_, err := mm.Builder.RawSql(sql).Exec()
-					if err != nil {
-						fmt.Println(err)
-					} else {
-						fmt.Println("修改索引:" + sql)
+					sql := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " MODIFY " + getIndexStr(indexCode)
+					if err := mm.exec(sql, "修改索引:"+sql); err != nil {
+						return err
 					}
 				}
 			}
 		}
 
 		if isFind == 0 {
-			mm.createIndex(tableFromCode.TableName.String, indexCode)
+			if err := mm.createIndex(tableFromCode.TableName.String, indexCode); err != nil {
+				return err
+			}
 		}
 	}
+
+	return nil
 }
 
-func (mm *MigrateExecutor) modifyTableComment(tableFromCode Table) {
-	sql := "ALTER TABLE " + tableFromCode.TableName.String + " Comment " + tableFromCode.TableComment.String
-	_, err := mm.Builder.RawSql(sql).Exec()
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		fmt.Println("修改表:" + sql)
-	}
+func (mm *MigrateExecutor) modifyTableComment(tableFromCode Table) error {
+	sql := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " Comment " + tableFromCode.TableComment.String
+	return mm.exec(sql, "修改表:"+sql)
 }
 
-func (mm *MigrateExecutor) createTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index) {
+func (mm *MigrateExecutor) createTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index) error {
 	var fieldArr []string
 
 	for i := 0; i < len(columnsFromCode); i++ {
@@ -326,41 +323,64 @@ func (mm *MigrateExecutor) createTable(tableFromCode Table, columnsFromCode []Co
 	for i := 0; i < len(indexesFromCode); i++ {
 		index := indexesFromCode[i]
 		if index.KeyName.String == "PRIMARY" {
-			fieldArr = append(fieldArr, "PRIMARY KEY ("+index.ColumnName.String+")")
+			fieldArr = append(fieldArr, "PRIMARY KEY ("+quote(index.ColumnName.String)+")")
 		}
 	}
 
-	sql := "CREATE TABLE " + tableFromCode.TableName.String + " (\n" + strings.Join(fieldArr, ",\n") + "\n) " + ";"
+	sql := "CREATE TABLE " + quote(tableFromCode.TableName.String) + " (\n" + strings.Join(fieldArr, ",\n") + "\n) " + ";"
 
-	_, err := mm.Builder.RawSql(sql).Exec()
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		fmt.Println("创建表:" + tableFromCode.TableName.String)
+	if err := mm.exec(sql, "创建表:"+tableFromCode.TableName.String); err != nil {
+		return err
 	}
 
 	//创建其他索引
 	for i := 0; i < len(indexesFromCode); i++ {
 		index := indexesFromCode[i]
 		if index.KeyName.String != "PRIMARY" {
-			mm.createIndex(tableFromCode.TableName.String, index)
+			if err := mm.createIndex(tableFromCode.TableName.String, index); err != nil {
+				return err
+			}
 		}
 	}
+
+	return nil
 }
 
-func (mm *MigrateExecutor) createIndex(tableName string, index Index) {
+func (mm *MigrateExecutor) createIndex(tableName string, index Index) error {
 	keyType := ""
 	if index.NonUnique.Int64 == 0 {
 		keyType = "UNIQUE"
 	}
 
-	sql := "CREATE " + keyType + " INDEX " + index.KeyName.String + " on " + tableName + " (" + index.ColumnName.String + ")"
-	_, err := mm.Builder.RawSql(sql).Exec()
+	sql := "CREATE " + keyType + " INDEX " + quote(index.KeyName.String) + " on " + quote(tableName) + " (" + quote(index.ColumnName.String) + ")"
+	return mm.exec(sql, "增加索引:"+sql)
+}
+
+//exec 执行迁移语句,成功时记录 msg,失败时记录并返回错误
+func (mm *MigrateExecutor) exec(query string, msg string) error {
+	_, err := mm.Builder.RawSql(query).Exec()
 	if err != nil {
-		fmt.Println(err)
-	} else {
-		fmt.Println("增加索引:" + sql)
+		mm.logError(err)
+		return err
 	}
+
+	mm.logInfo(msg)
+	return nil
+}
+
+//logError 记录迁移中的错误
+func (mm *MigrateExecutor) logError(err error) {
+	mm.Builder.Logger().Log(mm.Builder.Context(), base.LogError, err.Error())
+}
+
+//logInfo 记录迁移中的信息
+func (mm *MigrateExecutor) logInfo(msg string) {
+	mm.Builder.Logger().Log(mm.Builder.Context(), base.LogInfo, msg)
+}
+
+//quote 给标识符加上双引号
+func quote(name string) string {
+	return utils.Quote(name, `"`, `"`)
 }
 
 func getTagMap(fieldTag string) map[string]string {
@@ -380,7 +400,7 @@ func getTagMap(fieldTag string) map[string]string {
 
 func getColumnStr(column Column, f string) string {
 	var strArr []string
-	strArr = append(strArr, column.ColumnName.String)
+	strArr = append(strArr, quote(column.ColumnName.String))
 
 	//类型
 	if column.Extra.String == "auto_increment" {
@@ -422,16 +442,16 @@ func getIndexStr(index Index) string {
 	if "PRIMARY" == index.KeyName.String {
 		strArr = append(strArr, index.KeyName.String)
 		strArr = append(strArr, "KEY")
-		strArr = append(strArr, "("+index.ColumnName.String+")")
+		strArr = append(strArr, "("+quote(index.ColumnName.String)+")")
 	} else {
 		if 0 == index.NonUnique.Int64 {
 			strArr = append(strArr, "Unique")
-			strArr = append(strArr, index.KeyName.String)
-			strArr = append(strArr, "("+index.ColumnName.String+")")
+			strArr = append(strArr, quote(index.KeyName.String))
+			strArr = append(strArr, "("+quote(index.ColumnName.String)+")")
 		} else {
 			strArr = append(strArr, "Index")
-			strArr = append(strArr, index.KeyName.String)
-			strArr = append(strArr, "("+index.ColumnName.String+")")
+			strArr = append(strArr, quote(index.KeyName.String))
+			strArr = append(strArr, "("+quote(index.ColumnName.String)+")")
 		}
 	}
 
@@ -1,7 +1,7 @@
 package migrate_sqlite3
 
 import (
-	"fmt"
+	"github.com/tangpanqing/aorm/base"
 	"github.com/tangpanqing/aorm/builder"
 	"github.com/tangpanqing/aorm/null"
 	"github.com/tangpanqing/aorm/utils"
@@ -47,12 +47,12 @@ type MigrateExecutor struct {
 //ShowCreateTable 查看创建表的ddl
 func (mm *MigrateExecutor) ShowCreateTable(tableName string) string {
 	var str string
-	mm.Builder.RawSql("show create table "+tableName).Value("Create Table", &str)
+	mm.Builder.RawSql("show create table "+quote(tableName)).Value("Create Table", &str)
 	return str
 }
 
 //MigrateCommon 迁移的主要过程
-func (mm *MigrateExecutor) MigrateCommon(tableName string, typeOf reflect.Type) error {
+func (mm *MigrateExecutor) MigrateCommon(tableName string, typeOf reflect.Type, valueOf reflect.Value) error {
 	tableFromCode := mm.getTableFromCode(tableName)
 	columnsFromCode := mm.getColumnsFromCode(typeOf)
 	indexesFromCode := mm.getIndexesFromCode(typeOf, tableFromCode)
@@ -62,18 +62,18 @@ func (mm *MigrateExecutor) MigrateCommon(tableName string, typeOf reflect.Type)
 		return dbErr
 	}
 
-	tablesFromDb := mm.getTableFromDb(dbName, tableName)
+	_, name := utils.SplitTableName(tableName)
+
+	tablesFromDb := mm.getTableFromDb(dbName, name)
 	if len(tablesFromDb) != 0 {
 		tableFromDb := tablesFromDb[0]
-		columnsFromDb := mm.getColumnsFromDb(dbName, tableName)
-		indexesFromDb := mm.getIndexesFromDb(tableName)
+		columnsFromDb := mm.getColumnsFromDb(dbName, name)
+		indexesFromDb := mm.getIndexesFromDb(name)
 
-		mm.modifyTable(tableFromCode, columnsFromCode, indexesFromCode, tableFromDb, columnsFromDb, indexesFromDb)
-	} else {
-		mm.createTable(tableFromCode, columnsFromCode, indexesFromCode)
+		return mm.modifyTable(tableFromCode, columnsFromCode, indexesFromCode, tableFromDb, columnsFromDb, indexesFromDb)
 	}
 
-	return nil
+	return mm.createTable(tableFromCode, columnsFromCode, indexesFromCode)
 }
 
 func (mm *MigrateExecutor) getTableFromCode(tableName string) Table {
@@ -86,8 +86,12 @@ func (mm *MigrateExecutor) getTableFromCode(tableName string) Table {
 func (mm *MigrateExecutor) getColumnsFromCode(typeOf reflect.Type) []Column {
 	var columnsFromCode []Column
 	for i := 0; i < typeOf.Elem().NumField(); i++ {
+		if !typeOf.Elem().Field(i).IsExported() {
+			continue
+		}
+
 		fieldName := utils.UnderLine(typeOf.Elem().Field(i).Name)
-		fieldType := typeOf.Elem().Field(i).Type.Name()
+		fieldType := utils.TypeName(typeOf.Elem().Field(i).Type)
 		fieldMap := getTagMap(typeOf.Elem().Field(i).Tag.Get("aorm"))
 		columnsFromCode = append(columnsFromCode, Column{
 			ColumnName:    null.StringFrom(fieldName),
@@ -103,6 +107,8 @@ func (mm *MigrateExecutor) getColumnsFromCode(typeOf reflect.Type) []Column {
 }
 
 func (mm *MigrateExecutor) getIndexesFromCode(typeOf reflect.Type, tableFromCode Table) []Index {
+	_, tableName := utils.SplitTableName(tableFromCode.TableName.String)
+
 	var indexesFromCode []Index
 	for i := 0; i < typeOf.Elem().NumField(); i++ {
 		fieldName := utils.UnderLine(typeOf.Elem().Field(i).Name)
@@ -122,7 +128,7 @@ func (mm *MigrateExecutor) getIndexesFromCode(typeOf reflect.Type, tableFromCode
 			indexesFromCode = append(indexesFromCode, Index{
 				NonUnique:  null.IntFrom(0),
 				ColumnName: null.StringFrom(fieldName),
-				KeyName:    null.StringFrom("idx_" + tableFromCode.TableName.String + "_" + fieldName),
+				KeyName:    null.StringFrom("idx_" + tableName + "_" + fieldName),
 			})
 		}
 
@@ -131,7 +137,7 @@ func (mm *MigrateExecutor) getIndexesFromCode(typeOf reflect.Type, tableFromCode
 			indexesFromCode = append(indexesFromCode, Index{
 				NonUnique:  null.IntFrom(1),
 				ColumnName: null.StringFrom(fieldName),
-				KeyName:    null.StringFrom("idx_" + tableFromCode.TableName.String + "_" + fieldName),
+				KeyName:    null.StringFrom("idx_" + tableName + "_" + fieldName),
 			})
 		}
 	}
@@ -178,7 +184,7 @@ func (mm *MigrateExecutor) getColumnsFromDb(dbName string, tableName string) []C
 		columnStr = strings.Replace(columnStr, "NOT NULL", "NOT_NULL", -1)
 		columnArr := strings.Split(columnStr, " ")
 
-		columnName := columnArr[0]
+		columnName := utils.Unquote(columnArr[0])
 		dataType := columnArr[1]
 		IsNullable := "YES"
 		if len(columnArr) >= 3 {
@@ -214,8 +220,8 @@ func (mm *MigrateExecutor) getIndexesFromDb(tableName string) []Index {
 
 		indexesFromDb = append(indexesFromDb, Index{
 			NonUnique:  null.IntFrom(int64(t)),
-			ColumnName: null.StringFrom(matchArr[0][2]),
-			KeyName:    null.StringFrom(matchArr[0][1]),
+			ColumnName: null.StringFrom(utils.Unquote(matchArr[0][2])),
+			KeyName:    null.StringFrom(utils.Unquote(matchArr[0][1])),
 		})
 	}
 
@@ -229,7 +235,7 @@ func (mm *MigrateExecutor) getIndexesFromDb(tableName string) []Index {
 	if len(matchArr2) > 0 {
 		indexesFromDb = append(indexesFromDb, Index{
 			NonUnique:  null.IntFrom(0),
-			ColumnName: null.StringFrom(matchArr2[0][1]),
+			ColumnName: null.StringFrom(utils.Unquote(matchArr2[0][1])),
 			KeyName:    null.StringFrom("PRIMARY"),
 		})
 	}
@@ -237,7 +243,7 @@ func (mm *MigrateExecutor) getIndexesFromDb(tableName string) []Index {
 	return indexesFromDb
 }
 
-func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index, tableFromDb Table, columnsFromDb []Column, indexesFromDb []Index) {
+func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index, tableFromDb Table, columnsFromDb []Column, indexesFromDb []Index) error {
 	for i := 0; i < len(columnsFromCode); i++ {
 		isFind := 0
 		columnCode := columnsFromCode[i]
@@ -250,26 +256,18 @@ func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Co
 				if columnCode.DataType.String != columnDb.DataType.String ||
 					columnCode.ColumnDefault.String != columnDb.ColumnDefault.String {
 
-					query := "ALTER TABLE " + tableFromCode.TableName.String + " MODIFY " + getColumnStr(columnCode)
-					_, err := mm.Builder.RawSql(query).Exec()
-					if err != nil {
-						fmt.Println(query)
-						fmt.Println(err)
-					} else {
-						fmt.Println("修改属性:" + query)
+					query := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " MODIFY " + getColumnStr(columnCode)
+					if err := mm.exec(query, "修改属性:"+query); err != nil {
+						return err
 					}
 				}
 			}
 		}
 
 		if isFind == 0 {
-			query := "ALTER TABLE " + tableFromCode.TableName.String + " ADD " + getColumnStr(columnCode)
-			_, err := mm.Builder.RawSql(query).Exec()
-			if err != nil {
-				fmt.Println(query)
-				fmt.Println(err)
-			} else {
-				fmt.Println("增加属性:" + query)
+			query := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " ADD " + getColumnStr(columnCode)
+			if err := mm.exec(query, "增加属性:"+query); err != nil {
+				return err
 			}
 		}
 	}
@@ -283,24 +281,25 @@ func (mm *MigrateExecutor) modifyTable(tableFromCode Table, columnsFromCode []Co
 			if indexCode.ColumnName == indexDb.ColumnName {
 				isFind = 1
 				if indexCode.KeyName != indexDb.KeyName || indexCode.NonUnique != indexDb.NonUnique {
-					query := "ALTER TABLE " + tableFromCode.TableName.String + " MODIFY " + getIndexStr(indexCode)
-					_, err := mm.Builder.RawSql(query).Exec()
-					if err != nil {
-						fmt.Println(err)
-					} else {
-						fmt.Println("修改索引:" + query)
+					query := "ALTER TABLE " + quote(tableFromCode.TableName.String) + " MODIFY " + getIndexStr(indexCode)
+					if err := mm.exec(query, "修改索引:"+query); err != nil {
+						return err
 					}
 				}
 			}
 		}
 
 		if isFind == 0 {
-			mm.createIndex(tableFromCode.TableName.String, indexCode)
+			if err := mm.createIndex(tableFromCode.TableName.String, indexCode); err != nil {
+				return err
+			}
 		}
 	}
+
+	return nil
 }
 
-func (mm *MigrateExecutor) createTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index) {
+func (mm *MigrateExecutor) createTable(tableFromCode Table, columnsFromCode []Column, indexesFromCode []Index) error {
 	var fieldArr []string
 
 	for i := 0; i < len(columnsFromCode); i++ {
@@ -311,41 +310,64 @@ func (mm *MigrateExecutor) createTable(tableFromCode Table, columnsFromCode []Co
 	for i := 0; i < len(indexesFromCode); i++ {
 		index := indexesFromCode[i]
 		if index.KeyName.String == "PRIMARY" {
-			fieldArr = append(fieldArr, "PRIMARY KEY ("+index.ColumnName.String+")")
+			fieldArr = append(fieldArr, "PRIMARY KEY ("+quote(index.ColumnName.String)+")")
 		}
 	}
 
 	//创建表结构与主键索引
-	sql := "CREATE TABLE `" + tableFromCode.TableName.String + "` (\n" + strings.Join(fieldArr, ",\n") + "\n) " + ";"
-	_, err := mm.Builder.RawSql(sql).Exec()
-	if err != nil {
-		fmt.Println(err)
-	} else {
-		fmt.Println("创建表:" + tableFromCode.TableName.String)
+	sql := "CREATE TABLE " + quote(tableFromCode.TableName.String) + " (\n" + strings.Join(fieldArr, ",\n") + "\n) " + ";"
+	if err := mm.exec(sql, "创建表:"+tableFromCode.TableName.String); err != nil {
+		return err
 	}
 
 	//创建其他索引
 	for i := 0; i < len(indexesFromCode); i++ {
 		index := indexesFromCode[i]
 		if index.KeyName.String != "PRIMARY" {
-			mm.createIndex(tableFromCode.TableName.String, index)
+			if err := mm.createIndex(tableFromCode.TableName.String, index); err != nil {
+				return err
+			}
 		}
 	}
+
+	return nil
 }
 
-func (mm *MigrateExecutor) createIndex(tableName string, index Index) {
+func (mm *MigrateExecutor) createIndex(tableName string, index Index) error {
 	keyType := ""
 	if index.NonUnique.Int64 == 0 {
 		keyType = "UNIQUE"
 	}
 
-	sql := "CREATE " + keyType + " INDEX " + index.KeyName.String + " on " + tableName + " (" + index.ColumnName.String + ")"
-	_, err := mm.Builder.RawSql(sql).Exec()
+	sql := "CREATE " + keyType + " INDEX " + quote(index.KeyName.String) + " on " + quote(tableName) + " (" + quote(index.ColumnName.String) + ")"
+	return mm.exec(sql, "增加索引:"+sql)
+}
+
+//exec 执行迁移语句,成功时记录 msg,失败时记录并返回错误
+func (mm *MigrateExecutor) exec(query string, msg string) error {
+	_, err := mm.Builder.RawSql(query).Exec()
 	if err != nil {
-		fmt.Println(err)
-	} else {
-		fmt.Println("增加索引:" + sql)
+		mm.logError(err)
+		return err
 	}
+
+	mm.logInfo(msg)
+	return nil
+}
+
+//logError 记录迁移中的错误
+func (mm *MigrateExecutor) logError(err error) {
+	mm.Builder.Logger().Log(mm.Builder.Context(), base.LogError, err.Error())
+}
+
+//logInfo 记录迁移中的信息
+func (mm *MigrateExecutor) logInfo(msg string) {
+	mm.Builder.Logger().Log(mm.Builder.Context(), base.LogInfo, msg)
+}
+
+//quote 给标识符加上双引号
+func quote(name string) string {
+	return utils.Quote(name, `"`, `"`)
 }
 
 func getTagMap(fieldTag string) map[string]string {
@@ -365,7 +387,7 @@ func getTagMap(fieldTag string) map[string]string {
 
 func getColumnStr(column Column) string {
 	var strArr []string
-	strArr = append(strArr, column.ColumnName.String)
+	strArr = append(strArr, quote(column.ColumnName.String))
 
 	//类型
 	if column.MaxLength.Int64 == 0 {
@@ -403,16 +425,16 @@ func getIndexStr(index Index) string {
 	if "PRIMARY" == index.KeyName.String {
 		strArr = append(strArr, index.KeyName.String)
 		strArr = append(strArr, "KEY")
-		strArr = append(strArr, "(`"+index.ColumnName.String+"`)")
+		strArr = append(strArr, "("+quote(index.ColumnName.String)+")")
 	} else {
 		if 0 == index.NonUnique.Int64 {
 			strArr = append(strArr, "Unique")
-			strArr = append(strArr, index.KeyName.String)
-			strArr = append(strArr, "(`"+index.ColumnName.String+"`)")
+			strArr = append(strArr, quote(index.KeyName.String))
+			strArr = append(strArr, "("+quote(index.ColumnName.String)+")")
 		} else {
 			strArr = append(strArr, "Index")
-			strArr = append(strArr, index.KeyName.String)
-			strArr = append(strArr, "(`"+index.ColumnName.String+"`)")
+			strArr = append(strArr, quote(index.KeyName.String))
+			strArr = append(strArr, "("+quote(index.ColumnName.String)+")")
 		}
 	}
 
@@ -0,0 +1,53 @@
+package migrator
+
+import (
+	"fmt"
+	"github.com/tangpanqing/aorm/base"
+	"github.com/tangpanqing/aorm/builder"
+	"github.com/tangpanqing/aorm/driver"
+	"github.com/tangpanqing/aorm/migrate_mssql"
+	"github.com/tangpanqing/aorm/migrate_mysql"
+	"github.com/tangpanqing/aorm/migrate_postgres"
+	"github.com/tangpanqing/aorm/migrate_sqlite3"
+	"reflect"
+)
+
+//Executor 迁移执行者,每种数据库各自实现
+type Executor interface {
+	ShowCreateTable(tableName string) string
+	MigrateCommon(tableName string, typeOf reflect.Type, valueOf reflect.Value) error
+}
+
+//MigrateDialect 可选接口,方言提供自己的迁移执行者,没有实现时按 Flavor 使用内置的迁移执行者
+type MigrateDialect interface {
+	MigrateExecutor(link base.Link) Executor
+}
+
+//builtinExecutorMap 内置数据库的迁移执行者
+var builtinExecutorMap = map[string]func(link base.Link) Executor{
+	driver.Mysql: func(link base.Link) Executor {
+		return &migrate_mysql.MigrateExecutor{Builder: &builder.Builder{Link: link}}
+	},
+	driver.Mssql: func(link base.Link) Executor {
+		return &migrate_mssql.MigrateExecutor{Builder: &builder.Builder{Link: link}}
+	},
+	driver.Postgres: func(link base.Link) Executor {
+		return &migrate_postgres.MigrateExecutor{Builder: &builder.Builder{Link: link}}
+	},
+	driver.Sqlite3: func(link base.Link) Executor {
+		return &migrate_sqlite3.MigrateExecutor{Builder: &builder.Builder{Link: link}}
+	},
+}
+
+//getExecutor 获取迁移执行者,方言实现了 MigrateDialect 时由方言提供,否则按方言所属的内置数据库查找
+func (mi *Migrator) getExecutor() (Executor, error) {
+	dialect := driver.GetDialect(mi.Link.DriverName())
+	if d, ok := dialect.(MigrateDialect); ok {
+		return d.MigrateExecutor(mi.Link), nil
+	}
+	if fn, ok := builtinExecutorMap[dialect.Flavor()]; ok {
+		return fn(mi.Link), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s migrate", builder.ErrNotSupported, mi.Link.DriverName())
+}
@@ -1,6 +1,9 @@
 package test
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	_ "github.com/denisenkom/go-mssqldb"
 	_ "github.com/go-sql-driver/mysql"
@@ -11,6 +14,7 @@ import (
 	"github.com/tangpanqing/aorm/builder"
 	"github.com/tangpanqing/aorm/driver"
 	"github.com/tangpanqing/aorm/null"
+	"strings"
 	"testing"
 	"time"
 )
@@ -84,17 +88,89 @@ type PersonWithArticleCount struct {
 	ArticleCount null.Int    `aorm:"comment:文章数量" json:"articleCount"`
 }
 
+type HookPerson struct {
+	Id   null.Int    `aorm:"primary;auto_increment" json:"id"`
+	Name null.String `aorm:"size:100;not null;comment:名字" json:"name"`
+}
+
+func (p *HookPerson) TableName() string {
+	return "person"
+}
+
+func (p *HookPerson) BeforeInsert(link base.Link) error {
+	if p.Name.String == "" {
+		return errors.New("name is empty")
+	}
+	return nil
+}
+
+func (p *HookPerson) AfterFind(link base.Link) error {
+	p.Name = null.StringFrom("found " + p.Name.String)
+	return nil
+}
+
+type PersonRank struct {
+	Id       null.Int
+	Type     null.Int
+	Age      null.Int
+	RowNum   null.Int
+	PrevAge  null.Int
+	AgeTotal null.Int
+}
+
+type PersonExpr struct {
+	Id       null.Int
+	Label    null.String
+	NextAge  null.Int
+	MoneyStr null.String
+}
+
+type PlainPerson struct {
+	Id         int64           `aorm:"primary;auto_increment" json:"id"`
+	Name       string          `aorm:"size:100;not null;comment:名字" json:"name"`
+	Age        *int64          `aorm:"index;comment:年龄" json:"age"`
+	Money      sql.NullFloat64 `aorm:"comment:金额" json:"money"`
+	CreateTime time.Time       `aorm:"comment:创建时间" json:"createTime"`
+}
+
+func (p *PlainPerson) TableName() string {
+	return "person"
+}
+
+type Keyword struct {
+	Id    null.Int    `aorm:"primary;auto_increment" json:"id"`
+	Order null.Int    `aorm:"index;comment:排序" json:"order"`
+	Group null.String `aorm:"size:100;comment:分组" json:"group"`
+	User  null.String `aorm:"size:100;comment:用户" json:"user"`
+}
+
+type memoryLogger struct {
+	entries []base.QueryLog
+}
+
+func (l *memoryLogger) LogQuery(ctx context.Context, entry base.QueryLog) {
+	l.entries = append(l.entries, entry)
+}
+
+func (l *memoryLogger) Log(ctx context.Context, level base.LogLevel, msg string) {
+}
+
 var student = Student{}
 var person = Person{}
 var article = Article{}
 var articleVO = ArticleVO{}
 var personAge = PersonAge{}
+var personRank = PersonRank{}
+var personExpr = PersonExpr{}
+var keyword = Keyword{}
 var personWithArticleCount = PersonWithArticleCount{}
 
 func TestAll(t *testing.T) {
 	aorm.Store(&person, &article, &student)
 	aorm.Store(&articleVO)
 	aorm.Store(&personAge, &personWithArticleCount)
+	aorm.Store(&personRank, &personExpr)
+	aorm.Store(&keyword)
 
 	var dbList = []*base.Db{
 		testMysqlConnect(),
@@ -160,6 +236,31 @@ func TestAll(t *testing.T) {
 		testRawSql(dbItem, id2)
 
 		testTransaction(dbItem)
+		testManagedTransaction(dbItem)
+		testWithContext(dbItem)
+		testLogger(dbItem)
+		testHook(dbItem)
+		testError(dbItem, id2)
+		testCluster(dbItem)
+		testStmtCache(dbItem)
+		testRegistry(dbItem)
+		testEachAndChunk(dbItem)
+		testPlainType(dbItem)
+		testWhereGroup(dbItem)
+		testUpsert(dbItem)
+		testJoinCondition(dbItem)
+		testUnion(dbItem)
+		testWith(dbItem)
+		testWindow(dbItem)
+		testExpr(dbItem)
+		testUpdateSet(dbItem)
+		testWriteLimit(dbItem)
+		testKeyword(dbItem)
+		testDialect(dbItem)
+		testOpenDB(dbItem)
+		testNamedParam(dbItem)
+		testPaginate(dbItem)
+		testCursorPaginate(dbItem)
 		testTruncate(dbItem)
 
 	}
@@ -219,10 +320,36 @@ func testMssqlConnect() *base.Db {
 	return mssqlContent
 }
 
+//BadMigrate 字段类型错误,迁移时应返回错误
+type BadMigrate struct {
+	Id   null.Int    `aorm:"primary;auto_increment"`
+	Name null.String `aorm:"type:notatype((("`
+}
+
 func testMigrate(db *base.Db) {
-	aorm.Migrator(db).AutoMigrate(&person, &article, &student)
+	err := aorm.Migrator(db).AutoMigrate(&person, &article, &student, &keyword)
+	if err != nil {
+		panic(db.DriverName() + " testMigrate " + "found err:" + err.Error())
+	}
+
+	err = aorm.Migrator(db).Migrate("person_1", &person)
+	if err != nil {
+		panic(db.DriverName() + " testMigrate " + "found err:" + err.Error())
+	}
+
+	//错误的字段定义返回错误
+	err = aorm.Migrator(db).Migrate("bad_migrate", &BadMigrate{})
+	if err == nil {
+		panic(db.DriverName() + " testMigrate " + "bad definition should return err")
+	}
 
-	aorm.Migrator(db).Migrate("person_1", &person)
+	//没有迁移执行者的方言返回错误
+	name := db.DriverName() + "_unknown"
+	driver.Register(name, customDialect{Dialect: driver.GetDialect("unknown"), name: name})
+	err = aorm.Migrator(&base.Db{Driver: name, SqlDB: db.SqlDB}).Migrate("person_1", &person)
+	if !errors.Is(err, aorm.ErrNotSupported) {
+		panic(db.DriverName() + " testMigrate " + "unknown dialect should return ErrNotSupported")
+	}
 }
 
 func testShowCreateTable(db *base.Db) {
@@ -705,7 +832,10 @@ func testRawSql(db *base.Db, id2 int64) {
 }
 
 func testTransaction(db *base.Db) {
-	tx := db.Begin()
+	tx, errBegin := db.Begin()
+	if errBegin != nil {
+		panic(db.DriverName() + " testTransaction " + "found err:" + errBegin.Error())
+	}
 
 	id, errInsert := aorm.Db(tx).Insert(&Person{
 		Name: null.StringFrom("Alice"),
@@ -747,6 +877,425 @@ func testTransaction(db *base.Db) {
 	tx.Commit()
 }
 
+func testManagedTransaction(db *base.Db) {
+	var outerId int64
+	var innerId int64
+	err := db.Transaction(func(tx *base.Tx) error {
+		id, errInsert := aorm.Db(tx).Insert(&Person{Name: null.StringFrom("Outer")})
+		if errInsert != nil {
+			return errInsert
+		}
+		outerId = id
+
+		errNested := tx.Transaction(func(tx *base.Tx) error {
+			id, errInsert := aorm.Db(tx).Insert(&Person{Name: null.StringFrom("Inner")})
+			if errInsert != nil {
+				return errInsert
+			}
+			innerId = id
+			return errors.New("rollback inner")
+		})
+		if errNested == nil {
+			return errors.New("nested transaction should return err")
+		}
+
+		return nil
+	})
+	if err != nil {
+		panic(db.DriverName() + " testManagedTransaction " + "found err:" + err.Error())
+	}
+
+	if !testExists(db, outerId) {
+		panic(db.DriverName() + " testManagedTransaction " + "outer record should exist")
+	}
+
+	if testExists(db, innerId) {
+		panic(db.DriverName() + " testManagedTransaction " + "inner record should be rolled back")
+	}
+
+	errRollback := db.TransactionTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelDefault}, func(tx *base.Tx) error {
+		_, errDelete := aorm.Db(tx).Table(&person).WhereEq(&person.Id, outerId).Delete()
+		if errDelete != nil {
+			return errDelete
+		}
+		return errors.New("rollback all")
+	})
+	if errRollback == nil || !testExists(db, outerId) {
+		panic(db.DriverName() + " testManagedTransaction " + "delete should be rolled back")
+	}
+}
+
+func testWithContext(db *base.Db) {
+	var list []Person
+	err := aorm.Db(db).WithContext(context.Background()).Table(&person).WhereEq(&person.Type, 0).GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testWithContext " + "found err:" + err.Error())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var list2 []Person
+	err2 := aorm.Db(db).WithContext(ctx).Table(&person).WhereEq(&person.Type, 0).GetMany(&list2)
+	if err2 == nil {
+		panic(db.DriverName() + " testWithContext " + "cancelled context should return err")
+	}
+}
+
+func testLogger(db *base.Db) {
+	logger := &memoryLogger{}
+	db.SetLogger(logger)
+	defer db.SetLogger(nil)
+
+	_, err := aorm.Db(db).Table(&person).WhereEq(&person.Age, 18).Count("*")
+	if err != nil {
+		panic(db.DriverName() + " testLogger " + "found err:" + err.Error())
+	}
+
+	if len(logger.entries) != 1 || logger.entries[0].Sql == "" || logger.entries[0].Driver != db.DriverName() {
+		panic(db.DriverName() + " testLogger " + "query not logged")
+	}
+
+	_, err2 := aorm.Db(db).RawSql("SELECT * FROM table_not_exists").Exec()
+	if err2 == nil || len(logger.entries) != 2 || logger.entries[1].Err == nil {
+		panic(db.DriverName() + " testLogger " + "error not logged")
+	}
+}
+
+func testHook(db *base.Db) {
+	_, err := aorm.Db(db).Insert(&HookPerson{})
+	if err == nil {
+		panic(db.DriverName() + " testHook " + "BeforeInsert should abort insert")
+	}
+
+	id, err := aorm.Db(db).Insert(&HookPerson{Name: null.StringFrom("Hook")})
+	if err != nil {
+		panic(db.DriverName() + " testHook " + "found err:" + err.Error())
+	}
+
+	var item HookPerson
+	err = aorm.Db(db).Table(&person).WhereEq(&person.Id, id).OrderBy(&person.Id, builder.Desc).GetOne(&item)
+	if err != nil || item.Name.String != "found Hook" {
+		panic(db.DriverName() + " testHook " + "AfterFind not called")
+	}
+
+	var list []HookPerson
+	err = aorm.Db(db).Table(&person).WhereEq(&person.Id, id).GetMany(&list)
+	if err != nil || len(list) != 1 || list[0].Name.String != "found Hook" {
+		panic(db.DriverName() + " testHook " + "AfterFind not called for list")
+	}
+
+	var idInTx int64
+	err = db.Transaction(func(tx *base.Tx) error {
+		var errInsert error
+		idInTx, errInsert = aorm.Db(tx).Insert(&HookPerson{Name: null.StringFrom("Hook In Tx")})
+		if errInsert != nil {
+			return errInsert
+		}
+
+		_, errInsert = aorm.Db(tx).Insert(&HookPerson{})
+		return errInsert
+	})
+	if err == nil {
+		panic(db.DriverName() + " testHook " + "BeforeInsert should abort insert in tx")
+	}
+	if testExists(db, idInTx) {
+		panic(db.DriverName() + " testHook " + "tx should be rolled back")
+	}
+
+	//钩子出错时直接回滚事务,不需要调用方处理
+	tx, err := db.Begin()
+	if err != nil {
+		panic(db.DriverName() + " testHook " + "found err:" + err.Error())
+	}
+	idInTx, err = aorm.Db(tx).Insert(&HookPerson{Name: null.StringFrom("Hook In Tx")})
+	if err != nil {
+		panic(db.DriverName() + " testHook " + "found err:" + err.Error())
+	}
+	if _, err = aorm.Db(tx).Insert(&HookPerson{}); err == nil {
+		panic(db.DriverName() + " testHook " + "BeforeInsert should abort insert in tx")
+	}
+	if tx.Commit() == nil {
+		panic(db.DriverName() + " testHook " + "tx should be rolled back by hook error")
+	}
+	if testExists(db, idInTx) {
+		panic(db.DriverName() + " testHook " + "tx should be rolled back")
+	}
+}
+
+func testError(db *base.Db, id int64) {
+	var personItem Person
+	err := aorm.Db(db).Table(&person).WhereEq(&person.Id, -1).OrderBy(&person.Id, builder.Desc).GetOne(&personItem)
+	if !errors.Is(err, aorm.ErrNotFound) {
+		panic(db.DriverName() + " testError " + "expected ErrNotFound")
+	}
+
+	var list []Person
+	err = aorm.Db(db).GetMany(&list)
+	if !errors.Is(err, aorm.ErrMissingTable) {
+		panic(db.DriverName() + " testError " + "expected ErrMissingTable")
+	}
+
+	var batch []*Person
+	_, err = aorm.Db(db).InsertBatch(&batch)
+	if !errors.Is(err, aorm.ErrEmptyBatch) {
+		panic(db.DriverName() + " testError " + "expected ErrEmptyBatch")
+	}
+
+	//mssql不允许直接写入自增列
+	if db.DriverName() == driver.Mssql {
+		return
+	}
+
+	_, err = aorm.Db(db).Insert(&Person{Id: null.IntFrom(id), Name: null.StringFrom("Duplicate")})
+	if !errors.Is(err, aorm.ErrDuplicateKey) {
+		panic(db.DriverName() + " testError " + "expected ErrDuplicateKey")
+	}
+}
+
+//countingPolicy 记录选择从库的次数
+type countingPolicy struct {
+	base.RoundRobinPolicy
+	count int
+}
+
+func (p *countingPolicy) Choose(replicas []*sql.DB) *sql.DB {
+	p.count++
+	return p.RoundRobinPolicy.Choose(replicas)
+}
+
+//testReplicaConnect 连接同一个数据库,作为单独的从库连接池
+func testReplicaConnect(db *base.Db) *base.Db {
+	switch db.DriverName() {
+	case driver.Mysql:
+		return testMysqlConnect()
+	case driver.Postgres:
+		return testPostgresConnect()
+	case driver.Mssql:
+		return testMssqlConnect()
+	default:
+		return testSqlite3Connect()
+	}
+}
+
+func testCluster(db *base.Db) {
+	replicaDb := testReplicaConnect(db)
+	defer replicaDb.Close()
+
+	policy := &countingPolicy{}
+	cluster := base.NewCluster(db, []*sql.DB{replicaDb.SqlDB}, policy)
+	cluster.SetStmtCacheSize(10)
+	defer cluster.SetStmtCacheSize(0)
+
+	id, err := aorm.Db(cluster).Insert(&Person{Name: null.StringFrom("Cluster")})
+	if err != nil {
+		panic(db.DriverName() + " testCluster " + "found err:" + err.Error())
+	}
+	if policy.count != 0 {
+		panic(db.DriverName() + " testCluster " + "insert should use primary")
+	}
+
+	stmt, rows, err := aorm.Db(cluster).Table(&person).WhereEq(&person.Id, id).GetRows()
+	if err != nil {
+		panic(db.DriverName() + " testCluster " + "found err:" + err.Error())
+	}
+	rows.Close()
+	stmt.Close()
+	if policy.count != 1 {
+		panic(db.DriverName() + " testCluster " + "GetRows should use replica")
+	}
+
+	var name string
+	err = aorm.Db(cluster).Table(&person).WhereEq(&person.Id, id).Value(&person.Name, &name)
+	if err != nil || name != "Cluster" || policy.count != 2 {
+		panic(db.DriverName() + " testCluster " + "Value should use replica")
+	}
+
+	count, err := aorm.Db(cluster).Table(&person).WhereEq(&person.Id, id).Count("*")
+	if err != nil || count != 1 || policy.count != 3 {
+		panic(db.DriverName() + " testCluster " + "Count should use replica")
+	}
+
+	exists, err := aorm.Db(cluster).Table(&person).WhereEq(&person.Id, id).Exists()
+	if err != nil || !exists || policy.count != 4 {
+		panic(db.DriverName() + " testCluster " + "Exists should use replica")
+	}
+
+	count, err = aorm.Db(cluster).ForcePrimary().Table(&person).WhereEq(&person.Id, id).Count("*")
+	if err != nil || count != 1 || policy.count != 4 {
+		panic(db.DriverName() + " testCluster " + "ForcePrimary should use primary")
+	}
+
+	errTx := cluster.Transaction(func(tx *base.Tx) error {
+		_, err := aorm.Db(tx).Table(&person).WhereEq(&person.Id, id).Count("*")
+		return err
+	})
+	if errTx != nil || policy.count != 4 {
+		panic(db.DriverName() + " testCluster " + "tx should use primary")
+	}
+
+	//有分组时作为子查询统计,同样使用从库
+	var list []Person
+	page, err := aorm.Db(cluster).Table(&person).Select(&person.Age).WhereEq(&person.Id, id).GroupBy(&person.Age).Paginate(100, 10, &list)
+	if err != nil || page.Total != 1 || policy.count != 5 {
+		panic(db.DriverName() + " testCluster " + "grouped paginate count should use replica")
+	}
+
+	//从库在创建时只生成一次,并带有预处理语句缓存
+	replica, ok := cluster.Replica().(*base.Db)
+	if !ok || replica == db || cluster.Replica() != replica {
+		panic(db.DriverName() + " testCluster " + "replica should be created once")
+	}
+	if stats := replica.StmtCacheStats(); stats.Misses == 0 {
+		panic(db.DriverName() + " testCluster " + "replica should use stmt cache")
+	}
+}
+
+func testStmtCache(db *base.Db) {
+	db.SetStmtCacheSize(10)
+	defer db.SetStmtCacheSize(0)
+
+	for i := 0; i < 3; i++ {
+		_, err := aorm.Db(db).Table(&person).WhereEq(&person.Age, 18).Count("*")
+		if err != nil {
+			panic(db.DriverName() + " testStmtCache " + "found err:" + err.Error())
+		}
+	}
+
+	//只有一个连接时,事务中未缓存的语句在事务内预处理,不能等待连接池
+	db.SetMaxOpenConns(1)
+	defer db.SetMaxOpenConns(0)
+
+	errTx := db.Transaction(func(tx *base.Tx) error {
+		_, err := aorm.Db(tx).Table(&person).WhereEq(&person.Age, 18).Count("*")
+		if err != nil {
+			return err
+		}
+		_, err = aorm.Db(tx).Table(&person).WhereGt(&person.Age, 18).Count("*")
+		return err
+	})
+	if errTx != nil {
+		panic(db.DriverName() + " testStmtCache " + "found err:" + errTx.Error())
+	}
+
+	stats := db.StmtCacheStats()
+	if stats.Misses != 2 || stats.Hits != 3 || stats.Len != 1 {
+		panic(db.DriverName() + " testStmtCache " + fmt.Sprintf("unexpected stats %+v", stats))
+	}
+}
+
+func testRegistry(db *base.Db) {
+	var list []Person
+	other := Person{}
+	err := aorm.Db(db).Table(&Person{}).WhereEq(&other.Age, 18).GetMany(&list)
+	if !errors.Is(err, aorm.ErrUnregisteredField) {
+		panic(db.DriverName() + " testRegistry " + "expected ErrUnregisteredField")
+	}
+
+	registry := builder.NewRegistry()
+	registry.Store(&other)
+
+	err = aorm.Db(db).Registry(registry).Table(&Person{}).WhereEq(&other.Age, 18).OrderBy(&other.Id, builder.Desc).GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testRegistry " + "found err:" + err.Error())
+	}
+}
+
+func testEachAndChunk(db *base.Db) {
+	total, err := aorm.Db(db).Table(&person).WhereEq(&person.Type, 0).Count("*")
+	if err != nil {
+		panic(db.DriverName() + " testEachAndChunk " + "found err:" + err.Error())
+	}
+
+	var count int64
+	var item Person
+	err = aorm.Db(db).Table(&person).WhereEq(&person.Type, 0).Each(&item, func() error {
+		count++
+		return nil
+	})
+	if err != nil || count != total {
+		panic(db.DriverName() + " testEachAndChunk " + "Each should visit every row")
+	}
+
+	count = 0
+	var list []Person
+	err = aorm.Db(db).Table(&person).WhereEq(&person.Type, 0).Chunk(2, &list, func() error {
+		count += int64(len(list))
+		return nil
+	})
+	if err != nil || count != total {
+		panic(db.DriverName() + " testEachAndChunk " + "Chunk should visit every row")
+	}
+
+	//条件中有 OR 时,主键条件不能与 OR 混在一起,否则不会结束
+	totalOr, err := aorm.Db(db).Table(&person).WhereEq(&person.Type, 0).WhereOrEq(&person.Type, 1).Count("*")
+	if err != nil {
+		panic(db.DriverName() + " testEachAndChunk " + "found err:" + err.Error())
+	}
+
+	count = 0
+	err = aorm.Db(db).Table(&person).WhereEq(&person.Type, 0).WhereOrEq(&person.Type, 1).Chunk(2, &list, func() error {
+		count += int64(len(list))
+		if count > totalOr {
+			return errors.New("chunk visits more rows than expected")
+		}
+		return nil
+	})
+	if err != nil || count != totalOr {
+		panic(db.DriverName() + " testEachAndChunk " + "Chunk with OR should visit every row once")
+	}
+
+	//关联查询时主键带上表名,避免字段不明确
+	totalJoin, err := aorm.Db(db).Table(&article).Count("*")
+	if err != nil {
+		panic(db.DriverName() + " testEachAndChunk " + "found err:" + err.Error())
+	}
+
+	count = 0
+	var articleList []Article
+	err = aorm.Db(db).
+		Table(&article).
+		LeftJoin(&person, []builder.JoinCondition{
+			builder.GenJoinCondition(&person.Id, builder.RawEq, &article.PersonId),
+		}).
+		SelectAll(&article).
+		Chunk(1, &articleList, func() error {
+			count += int64(len(articleList))
+			return nil
+		})
+	if err != nil || count != totalJoin {
+		panic(db.DriverName() + " testEachAndChunk " + "Chunk with join should visit every row")
+	}
+
+	//派生表没有别名时返回 ErrMissingAlias
+	sub := aorm.Db(db).Table(&person)
+	err = aorm.Db(db).Table(&sub).Chunk(1, &list, func() error {
+		return nil
+	})
+	if !errors.Is(err, aorm.ErrMissingAlias) {
+		panic(db.DriverName() + " testEachAndChunk " + "Chunk on derived table without alias should return ErrMissingAlias")
+	}
+
+	for _, size := range []int{0, -1} {
+		err = aorm.Db(db).Table(&person).Chunk(size, &list, func() error {
+			return nil
+		})
+		if !errors.Is(err, aorm.ErrInvalidPageSize) {
+			panic(db.DriverName() + " testEachAndChunk " + "Chunk should reject invalid size")
+		}
+	}
+
+	errStop := errors.New("stop")
+	err = db.Transaction(func(tx *base.Tx) error {
+		return aorm.Db(tx).Table(&person).Chunk(1, &list, func() error {
+			return errStop
+		})
+	})
+	if total > 0 && err != errStop {
+		panic(db.DriverName() + " testEachAndChunk " + "Chunk should stop when callback returns err")
+	}
+}
+
 func testTruncate(db *base.Db) {
 	_, err := aorm.Db(db).Table(&person).Truncate()
 	if err != nil {
@@ -823,7 +1372,10 @@ func testDbContent() {
 		Name: null.StringFrom("test name"),
 	})
 
-	tx := db.Begin()
+	tx, err := db.Begin()
+	if err != nil {
+		panic(err)
+	}
 	aorm.Db(tx).Insert(&Person{
 		Name: null.StringFrom("test name"),
 	})
@@ -836,3 +1388,995 @@ func closeAll(dbList []*base.Db) {
 		dbList[i].Close()
 	}
 }
+
+func testPlainType(db *base.Db) {
+	age := int64(18)
+	id, err := aorm.Db(db).Insert(&PlainPerson{
+		Name:       "Plain",
+		Age:        &age,
+		Money:      sql.NullFloat64{Float64: 1.5, Valid: true},
+		CreateTime: time.Now(),
+	})
+	if err != nil {
+		panic(db.DriverName() + " testPlainType " + "found err:" + err.Error())
+	}
+
+	var item PlainPerson
+	err = aorm.Db(db).Table(&person).WhereEq(&person.Id, id).GetOne(&item)
+	if err != nil {
+		panic(db.DriverName() + " testPlainType " + "found err:" + err.Error())
+	}
+	if item.Id != id || item.Name != "Plain" || item.Age == nil || *item.Age != 18 || item.Money.Float64 != 1.5 {
+		panic(db.DriverName() + " testPlainType " + "plain fields not round-tripped")
+	}
+
+	id2, err := aorm.Db(db).Insert(&PlainPerson{Name: "PlainNull", CreateTime: time.Now()})
+	if err != nil {
+		panic(db.DriverName() + " testPlainType " + "found err:" + err.Error())
+	}
+
+	//bool 与 time.Time 按原类型绑定,而不是字符串
+	createTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	idTime, err := aorm.Db(db).Insert(&Person{Name: null.StringFrom("PlainTime"), Sex: null.BoolFrom(true), CreateTime: null.TimeFrom(createTime)})
+	if err != nil {
+		panic(db.DriverName() + " testPlainType " + "found err:" + err.Error())
+	}
+	count, err := aorm.Db(db).Table(&person).WhereEq(&person.Id, idTime).WhereEq(&person.Sex, true).WhereEq(&person.CreateTime, createTime).Count("*")
+	if err != nil {
+		panic(db.DriverName() + " testPlainType " + "found err:" + err.Error())
+	}
+	if count != 1 {
+		panic(db.DriverName() + " testPlainType " + "bool and time values should be bound as is")
+	}
+
+	var item2 PlainPerson
+	err = aorm.Db(db).Table(&person).Where(&PlainPerson{Id: id2}).GetOne(&item2)
+	if err != nil {
+		panic(db.DriverName() + " testPlainType " + "found err:" + err.Error())
+	}
+	if item2.Age != nil || item2.Money.Valid {
+		panic(db.DriverName() + " testPlainType " + "nil pointer should be stored as NULL")
+	}
+
+	_, err = aorm.Db(db).Table(&person).WhereIn(&person.Id, []int64{id, id2, idTime}).Delete()
+	if err != nil {
+		panic(db.DriverName() + " testPlainType " + "found err:" + err.Error())
+	}
+}
+
+func testWhereGroup(db *base.Db) {
+	var list []Person
+	err := aorm.Db(db).Table(&person).WhereGroup(func(b *builder.Builder) {
+		b.WhereEq(&person.Age, 18).WhereOrEq(&person.Age, 20)
+	}).WhereEq(&person.Type, 0).OrWhereGroup(func(b *builder.Builder) {
+		b.WhereIn(&person.Id, []int{1, 2}).WhereOrIsNull(&person.Name)
+	}).GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testWhereGroup " + "found err:" + err.Error())
+	}
+
+	_, err = aorm.Db(db).Table(&person).WhereGroup(func(b *builder.Builder) {
+		b.WhereEq(&person.Id, -1).WhereOrEq(&person.Id, -2)
+	}).Update(&Person{Type: null.IntFrom(0)})
+	if err != nil {
+		panic(db.DriverName() + " testWhereGroup " + "found err:" + err.Error())
+	}
+
+	var personAgeList []PersonAge
+	err = aorm.Db(db).
+		Table(&person).
+		Select(&person.Age).
+		SelectCount(&person.Age, &personAge.AgeCount).
+		GroupBy(&person.Age).
+		HavingGroup(func(b *builder.Builder) {
+			b.HavingGt(&personAge.AgeCount, 1).HavingOrEq(&personAge.AgeCount, 1)
+		}).
+		GetMany(&personAgeList)
+	if err != nil {
+		panic(db.DriverName() + " testWhereGroup " + "found err:" + err.Error())
+	}
+
+	_, err = aorm.Db(db).Table(&person).WhereEq(&person.Id, -1).WhereOrEq(&person.Id, -2).Delete()
+	if err != nil {
+		panic(db.DriverName() + " testWhereGroup " + "found err:" + err.Error())
+	}
+}
+
+func testUpsert(db *base.Db) {
+	id, err := aorm.Db(db).Insert(&Person{Name: null.StringFrom("Upsert"), Age: null.IntFrom(1)})
+	if err != nil {
+		panic(db.DriverName() + " testUpsert " + "found err:" + err.Error())
+	}
+
+	_, err = aorm.Db(db).Upsert(&Person{Id: null.IntFrom(id), Name: null.StringFrom("Upsert"), Age: null.IntFrom(2)}, nil, []interface{}{&person.Age})
+	if err != nil {
+		panic(db.DriverName() + " testUpsert " + "found err:" + err.Error())
+	}
+
+	var item Person
+	err = aorm.Db(db).Table(&person).WhereEq(&person.Id, id).GetOne(&item)
+	if err != nil || item.Age.Int64 != 2 {
+		panic(db.DriverName() + " testUpsert " + "conflict row should be updated")
+	}
+
+	_, err = aorm.Db(db).UpsertBatch(&[]*Person{
+		{Id: null.IntFrom(id), Name: null.StringFrom("Upsert"), Age: null.IntFrom(3)},
+	}, []interface{}{&person.Id}, nil)
+	if err != nil {
+		panic(db.DriverName() + " testUpsert " + "found err:" + err.Error())
+	}
+
+	err = aorm.Db(db).Table(&person).WhereEq(&person.Id, id).GetOne(&item)
+	if err != nil || item.Age.Int64 != 2 {
+		panic(db.DriverName() + " testUpsert " + "conflict row should be kept")
+	}
+
+	_, err = aorm.Db(db).Table(&person).WhereEq(&person.Id, id).Delete()
+	if err != nil {
+		panic(db.DriverName() + " testUpsert " + "found err:" + err.Error())
+	}
+
+	_, err = aorm.Db(db).Upsert(&Person{}, nil, nil)
+	if !errors.Is(err, aorm.ErrNoFields) {
+		panic(db.DriverName() + " testUpsert " + "expected ErrNoFields")
+	}
+
+	mixed := []*Person{{Name: null.StringFrom("Mixed"), Age: null.IntFrom(1)}, {Name: null.StringFrom("Mixed")}}
+	_, err = aorm.Db(db).UpsertBatch(&mixed, []interface{}{&person.Id}, nil)
+	if !errors.Is(err, aorm.ErrMixedColumns) {
+		panic(db.DriverName() + " testUpsert " + "expected ErrMixedColumns")
+	}
+
+	_, err = aorm.Db(db).InsertBatch(&mixed)
+	if !errors.Is(err, aorm.ErrMixedColumns) {
+		panic(db.DriverName() + " testUpsert " + "expected ErrMixedColumns from InsertBatch")
+	}
+
+	//Mysql 不使用冲突字段
+	if db.DriverName() == driver.Mysql {
+		return
+	}
+
+	//自增主键为零时不会写入,不能作为冲突字段
+	_, err = aorm.Db(db).Upsert(&Person{Name: null.StringFrom("Upsert")}, nil, []interface{}{&person.Age})
+	if !errors.Is(err, aorm.ErrMissingPrimaryKey) {
+		panic(db.DriverName() + " testUpsert " + "expected ErrMissingPrimaryKey")
+	}
+
+	_, err = aorm.Db(db).Upsert(&Person{Name: null.StringFrom("Upsert")}, []interface{}{&person.Age}, []interface{}{&person.Name})
+	if !errors.Is(err, aorm.ErrMissingConflictKey) {
+		panic(db.DriverName() + " testUpsert " + "expected ErrMissingConflictKey")
+	}
+}
+
+func testJoinCondition(db *base.Db) {
+	var list []ArticleVO
+	err := aorm.Db(db).
+		Table(&article).
+		LeftJoin(
+			&person,
+			[]builder.JoinCondition{
+				builder.GenJoinCondition(&person.Id, builder.RawEq, &article.PersonId),
+				builder.GenJoinCondition(&person.Age, builder.In, []int{18, 20}),
+				builder.GenJoinOrCondition(&person.Name, builder.IsNull, nil),
+				builder.GenJoinRawCondition("person.type >= ?", 0),
+			},
+		).
+		SelectAll(&article).
+		SelectAs(&person.Name, &articleVO.PersonName).
+		GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testJoinCondition " + "found err:" + err.Error())
+	}
+
+	sub := aorm.Db(db).Table(&person).Select(&person.Id).Select(&person.Name).WhereGt(&person.Age, 0)
+	err = aorm.Db(db).
+		Table(&article, "o").
+		Join(&sub, []builder.JoinCondition{
+			builder.GenJoinCondition("id", builder.RawEq, &article.PersonId, "o"),
+		}, "p").
+		Select("*", "o").
+		SelectAs("name", &articleVO.PersonName, "p").
+		GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testJoinCondition " + "found err:" + err.Error())
+	}
+
+	_, err = aorm.Db(db).Table(&article).CrossJoin(&person).Count("*")
+	if err != nil {
+		panic(db.DriverName() + " testJoinCondition " + "found err:" + err.Error())
+	}
+
+	err = aorm.Db(db).
+		Table(&article).
+		FullJoin(&person, []builder.JoinCondition{
+			builder.GenJoinCondition(&person.Id, builder.RawEq, &article.PersonId),
+		}).
+		SelectAll(&article).
+		GetMany(&list)
+	if db.DriverName() == driver.Mysql {
+		if !errors.Is(err, aorm.ErrNotSupported) {
+			panic(db.DriverName() + " testJoinCondition " + "full join should not be supported")
+		}
+	} else if err != nil {
+		panic(db.DriverName() + " testJoinCondition " + "found err:" + err.Error())
+	}
+}
+
+func testUnion(db *base.Db) {
+	var list []Person
+	err := aorm.Db(db).
+		Table(&person).
+		WhereLt(&person.Age, 18).
+		Union(aorm.Db(db).Table(&person).WhereGt(&person.Age, 20)).
+		UnionAll(aorm.Db(db).Table(&person).WhereEq(&person.Age, 18)).
+		OrderBy(&person.Id, builder.Desc).
+		Limit(0, 10).
+		GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testUnion " + "found err:" + err.Error())
+	}
+
+	err = aorm.Db(db).
+		Table(&person).
+		WhereGe(&person.Age, 18).
+		Except(aorm.Db(db).Table(&person).WhereEq(&person.Age, 20)).
+		GetMany(&list)
+	if err != nil && !errors.Is(err, aorm.ErrNotSupported) {
+		panic(db.DriverName() + " testUnion " + "found err:" + err.Error())
+	}
+
+	//只生成sql时不查询数据库版本,执行时同一个连接池只查询一次,并记录日志
+	fresh := testReplicaConnect(db)
+	defer fresh.Close()
+	logger := &memoryLogger{}
+	fresh.SetLogger(logger)
+
+	_, _, err = aorm.Db(fresh).Table(&person).Intersect(aorm.Db(fresh).Table(&person)).GetSqlAndParams()
+	if err != nil || len(logger.entries) != 0 {
+		panic(db.DriverName() + " testUnion " + "GetSqlAndParams should not query the database")
+	}
+
+	for i := 0; i < 2; i++ {
+		err = aorm.Db(fresh).Table(&person).Intersect(aorm.Db(fresh).Table(&person)).GetMany(&list)
+		if err != nil && !errors.Is(err, aorm.ErrNotSupported) {
+			panic(db.DriverName() + " testUnion " + "found err:" + err.Error())
+		}
+	}
+
+	versionCount := 0
+	versionDialect, hasVersion := driver.GetDialect(db.DriverName()).(driver.VersionDialect)
+	for i := 0; i < len(logger.entries); i++ {
+		if hasVersion && logger.entries[i].Sql == versionDialect.VersionQuery() {
+			versionCount++
+		}
+	}
+	if (hasVersion && versionCount != 1) || (!hasVersion && versionCount != 0) {
+		panic(db.DriverName() + " testUnion " + "server version should be queried once per pool")
+	}
+
+	//子查询没有分页时去掉排序
+	query, _, err := aorm.Db(db).
+		Table(&person).
+		Union(aorm.Db(db).Table(&person).WhereGt(&person.Age, 20).OrderBy(&person.Id, builder.Desc)).
+		GetSqlAndParams()
+	if err != nil {
+		panic(db.DriverName() + " testUnion " + "found err:" + err.Error())
+	}
+	if strings.Contains(query, "ORDER BY") {
+		panic(db.DriverName() + " testUnion " + "order without limit should be dropped in union query:" + query)
+	}
+
+	//子查询中的公用表表达式不能出现在合并查询中间
+	sub := aorm.Db(db).Table(&person).Select(&person.Id)
+	_, _, err = aorm.Db(db).
+		Table(&person).
+		Select(&person.Id).
+		Union(aorm.Db(db).With("adult", &sub).Table("adult").Select("id")).
+		GetSqlAndParams()
+	if !errors.Is(err, aorm.ErrNotSupported) {
+		panic(db.DriverName() + " testUnion " + "WITH in union query should not be supported")
+	}
+
+	//合并查询的子查询也检查数据库是否支持
+	name := db.DriverName() + "_nofeature"
+	driver.Register(name, noFeatureDialect{customDialect{Dialect: driver.GetDialect(db.DriverName()), name: name}})
+	noFeatureDb := &base.Db{Driver: name, SqlDB: db.SqlDB}
+	var articleList []Article
+	err = aorm.Db(noFeatureDb).
+		Table(&article).
+		SelectAll(&article).
+		Union(aorm.Db(noFeatureDb).Table(&article).FullJoin(&person, []builder.JoinCondition{
+			builder.GenJoinCondition(&person.Id, builder.RawEq, &article.PersonId),
+		}).SelectAll(&article)).
+		GetMany(&articleList)
+	if !errors.Is(err, aorm.ErrNotSupported) {
+		panic(db.DriverName() + " testUnion " + "full join in union query should be checked")
+	}
+}
+
+func testWith(db *base.Db) {
+	var list []Person
+	sub := aorm.Db(db).Table(&person).Select(&person.Id).WhereGe(&person.Age, 18)
+	ids := aorm.Db(db).Table("adult").Select("id")
+	err := aorm.Db(db).With("adult", &sub).Table(&person).WhereIn(&person.Id, &ids).GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testWith " + "found err:" + err.Error())
+	}
+
+	first := aorm.Db(db).Table(&person).WhereEq(&person.Type, 0).OrderBy(&person.Id, builder.Asc).Limit(0, 1)
+	anchor := aorm.Db(db).Table(&first, "f").Select("*", "f")
+	recursive := aorm.Db(db).
+		Table(&person, "p").
+		Select("*", "p").
+		Join("chain", []builder.JoinCondition{
+			builder.GenJoinRawCondition("p.id = c.id + 1"),
+		}, "c")
+	err = aorm.Db(db).WithRecursive("chain", &anchor, &recursive).Table("chain").GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testWith " + "found err:" + err.Error())
+	}
+
+	_, err = aorm.Db(db).With("adult", &sub).Table(&person).WhereIn(&person.Id, &ids).WhereEq(&person.Id, -1).Delete()
+	if err != nil {
+		panic(db.DriverName() + " testWith " + "found err:" + err.Error())
+	}
+}
+
+func testWindow(db *base.Db) {
+	var list []PersonRank
+	err := aorm.Db(db).
+		Table(&person).
+		Select(&person.Id).
+		Select(&person.Type).
+		Select(&person.Age).
+		SelectOver(builder.RowNumber, nil, &personRank.RowNum, builder.NewOver().PartitionBy(&person.Type).OrderBy(&person.Age, builder.Desc)).
+		SelectOver(builder.Lag, &person.Age, &personRank.PrevAge, builder.NewOver().Offset(1).OrderBy(&person.Id, builder.Asc)).
+		SelectOver("SUM", &person.Age, &personRank.AgeTotal, builder.NewOver().PartitionBy(&person.Type).OrderBy(&person.Id, builder.Asc).Rows(builder.UnboundedPreceding, builder.CurrentRow)).
+		OrderBy(&personRank.RowNum, builder.Asc).
+		GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testWindow " + "found err:" + err.Error())
+	}
+
+	sub := aorm.Db(db).
+		Table(&person).
+		Select(&person.Id).
+		Select(&person.Type).
+		SelectOver(builder.DenseRank, nil, &personRank.RowNum, builder.NewOver().PartitionBy(&person.Type).OrderBy(&person.Age, builder.Desc))
+	err = aorm.Db(db).Table(&sub, "t").Select("*", "t").WhereEq(&personRank.RowNum, 1, "t").GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testWindow " + "found err:" + err.Error())
+	}
+
+	//数据库不支持窗口函数时,执行前返回错误
+	name := db.DriverName() + "_nofeature"
+	driver.Register(name, noFeatureDialect{customDialect{Dialect: driver.GetDialect(db.DriverName()), name: name}})
+	noFeatureDb := &base.Db{Driver: name, SqlDB: db.SqlDB}
+	err = aorm.Db(noFeatureDb).
+		Table(&person).
+		Select(&person.Id).
+		SelectOver(builder.RowNumber, nil, &personRank.RowNum, nil).
+		GetMany(&list)
+	if !errors.Is(err, aorm.ErrNotSupported) {
+		panic(db.DriverName() + " testWindow " + "window function should be checked")
+	}
+
+	noFeatureSub := aorm.Db(noFeatureDb).
+		Table(&person).
+		Select(&person.Id).
+		SelectOver(builder.RowNumber, nil, &personRank.RowNum, nil)
+	err = aorm.Db(noFeatureDb).Table(&noFeatureSub, "t").Select("*", "t").GetMany(&list)
+	if !errors.Is(err, aorm.ErrNotSupported) {
+		panic(db.DriverName() + " testWindow " + "window function in derived table should be checked")
+	}
+}
+
+func testExpr(db *base.Db) {
+	var list []PersonExpr
+	err := aorm.Db(db).
+		Table(&person).
+		Select(&person.Id).
+		SelectAs(builder.CaseWhen(builder.Cmp(builder.Col(&person.Age), builder.Ge, 18), "adult").Else(builder.Coalesce(builder.Col(&person.Name), "anon")), &personExpr.Label).
+		SelectAs(builder.Add(builder.Col(&person.Age), 1), &personExpr.NextAge).
+		SelectAs(builder.Cast(builder.Col(&person.Money), builder.CastString), &personExpr.MoneyStr).
+		WhereGt(builder.Mul(builder.Col(&person.Age), 2), 20).
+		WhereLt(&person.Age, builder.Add(builder.Col(&person.Id), 1000)).
+		OrderBy(builder.Func("ABS", builder.Sub(builder.Col(&person.Age), 21)), builder.Asc).
+		GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testExpr " + "found err:" + err.Error())
+	}
+
+	for i := 0; i < len(list); i++ {
+		if list[i].Label.String == "" {
+			panic(db.DriverName() + " testExpr " + "case when should not be empty")
+		}
+	}
+
+	var personAgeList []PersonAge
+	err = aorm.Db(db).
+		Table(&person).
+		Select(&person.Age).
+		SelectCount(&person.Id, &personAge.AgeCount).
+		GroupBy(&person.Age).
+		HavingGt(builder.Func("COUNT", builder.Col(&person.Id)), 0).
+		GetMany(&personAgeList)
+	if err != nil {
+		panic(db.DriverName() + " testExpr " + "found err:" + err.Error())
+	}
+}
+
+func testUpdateSet(db *base.Db) {
+	id, err := aorm.Db(db).Insert(&Person{
+		Name:  null.StringFrom("Set"),
+		Age:   null.IntFrom(10),
+		Type:  null.IntFrom(1),
+		Money: null.FloatFrom(1.5),
+	})
+	if err != nil {
+		panic(db.DriverName() + " testUpdateSet " + "found err:" + err.Error())
+	}
+
+	_, err = aorm.Db(db).Table(&person).WhereEq(&person.Id, id).UpdateMap(map[interface{}]interface{}{})
+	if !errors.Is(err, aorm.ErrNoFields) {
+		panic(db.DriverName() + " testUpdateSet " + "expected ErrNoFields")
+	}
+
+	_, err = aorm.Db(db).
+		Table(&person).
+		WhereEq(&person.Id, id).
+		Set(&person.Age, builder.Add(builder.Col(&person.Age), 1)).
+		Set(&person.Money, builder.Add(builder.Col(&person.Money), 0.25)).
+		UpdateMap(map[interface{}]interface{}{
+			&person.Type: builder.Col(&person.Age),
+			&person.Name: "SetMap",
+		})
+	if err != nil {
+		panic(db.DriverName() + " testUpdateSet " + "found err:" + err.Error())
+	}
+
+	var p Person
+	err = aorm.Db(db).Table(&person).WhereEq(&person.Id, id).GetOne(&p)
+	if err != nil {
+		panic(db.DriverName() + " testUpdateSet " + "found err:" + err.Error())
+	}
+	if p.Age.Int64 != 11 || p.Type.Int64 != 10 || p.Money.Float64 != 1.75 || p.Name.String != "SetMap" {
+		panic(db.DriverName() + " testUpdateSet " + "update set not match")
+	}
+
+	_, err = aorm.Db(db).Table(&person).WhereEq(&person.Id, id).Increment(&person.Money, 0.5)
+	if err != nil {
+		panic(db.DriverName() + " testUpdateSet " + "found err:" + err.Error())
+	}
+
+	sub := aorm.Db(db).
+		Table(&article).
+		SelectCount(&article.Id, "article_count").
+		WhereRawEq(&article.PersonId, &person.Id)
+	_, err = aorm.Db(db).
+		Table(&person).
+		WhereEq(&person.Id, id).
+		Set(&person.Type, &sub).
+		Update(&Person{Name: null.StringFrom("SetSub")})
+	if err != nil {
+		panic(db.DriverName() + " testUpdateSet " + "found err:" + err.Error())
+	}
+
+	_, err = aorm.Db(db).Insert(&Article{Type: null.IntFrom(0), PersonId: null.IntFrom(id)})
+	if err != nil {
+		panic(db.DriverName() + " testUpdateSet " + "found err:" + err.Error())
+	}
+
+	_, err = aorm.Db(db).
+		Table(&article).
+		Join(
+			&person,
+			[]builder.JoinCondition{
+				builder.GenJoinCondition(&person.Id, builder.RawEq, &article.PersonId),
+			},
+		).
+		WhereEq(&person.Id, id).
+		Set(&article.Type, builder.Col(&person.Age)).
+		UpdateMap(nil)
+	if err != nil {
+		panic(db.DriverName() + " testUpdateSet " + "found err:" + err.Error())
+	}
+
+	var a Article
+	err = aorm.Db(db).Table(&article).WhereEq(&article.PersonId, id).GetOne(&a)
+	if err != nil {
+		panic(db.DriverName() + " testUpdateSet " + "found err:" + err.Error())
+	}
+	if a.Type.Int64 != 11 {
+		panic(db.DriverName() + " testUpdateSet " + "update join not match")
+	}
+}
+
+func testWriteLimit(db *base.Db) {
+	for i := 1; i <= 3; i++ {
+		id, err := aorm.Db(db).Insert(&Person{
+			Name: null.StringFrom("Limit"),
+			Age:  null.IntFrom(int64(i)),
+			Type: null.IntFrom(0),
+		})
+		if err != nil {
+			panic(db.DriverName() + " testWriteLimit " + "found err:" + err.Error())
+		}
+
+		_, err = aorm.Db(db).Insert(&Article{Type: null.IntFrom(0), PersonId: null.IntFrom(id)})
+		if err != nil {
+			panic(db.DriverName() + " testWriteLimit " + "found err:" + err.Error())
+		}
+	}
+
+	_, err := aorm.Db(db).
+		Table(&person).
+		WhereEq(&person.Name, "Limit").
+		OrderBy(&person.Age, builder.Desc).
+		Limit(0, 2).
+		Set(&person.Type, 99).
+		UpdateMap(nil)
+	if err != nil {
+		panic(db.DriverName() + " testWriteLimit " + "found err:" + err.Error())
+	}
+
+	count, err := aorm.Db(db).Table(&person).WhereEq(&person.Name, "Limit").WhereEq(&person.Type, 99).Count("*")
+	if err != nil {
+		panic(db.DriverName() + " testWriteLimit " + "found err:" + err.Error())
+	}
+	if count != 2 {
+		panic(db.DriverName() + " testWriteLimit " + "update limit not match")
+	}
+
+	_, err = aorm.Db(db).
+		Table(&article).
+		Join(
+			&person,
+			[]builder.JoinCondition{
+				builder.GenJoinCondition(&person.Id, builder.RawEq, &article.PersonId),
+			},
+		).
+		WhereEq(&person.Name, "Limit").
+		WhereEq(&person.Type, 99).
+		Delete()
+	if err != nil {
+		panic(db.DriverName() + " testWriteLimit " + "found err:" + err.Error())
+	}
+
+	_, err = aorm.Db(db).
+		Table(&person).
+		WhereEq(&person.Name, "Limit").
+		OrderBy(&person.Age, builder.Asc).
+		Limit(0, 1).
+		Delete()
+	if err != nil {
+		panic(db.DriverName() + " testWriteLimit " + "found err:" + err.Error())
+	}
+
+	count, err = aorm.Db(db).Table(&person).WhereEq(&person.Name, "Limit").WhereEq(&person.Age, 1).Count("*")
+	if err != nil {
+		panic(db.DriverName() + " testWriteLimit " + "found err:" + err.Error())
+	}
+	if count != 0 {
+		panic(db.DriverName() + " testWriteLimit " + "delete limit not match")
+	}
+}
+
+func testKeyword(db *base.Db) {
+	id, err := aorm.Db(db).Insert(&Keyword{
+		Order: null.IntFrom(1),
+		Group: null.StringFrom("Keyword"),
+		User:  null.StringFrom("Alice"),
+	})
+	if err != nil {
+		panic(db.DriverName() + " testKeyword " + "found err:" + err.Error())
+	}
+
+	_, err = aorm.Db(db).Table(&keyword).WhereEq(&keyword.Id, id).Update(&Keyword{Order: null.IntFrom(2)})
+	if err != nil {
+		panic(db.DriverName() + " testKeyword " + "found err:" + err.Error())
+	}
+
+	var list []Keyword
+	err = aorm.Db(db).
+		Table(&keyword, "k").
+		Select("*", "k").
+		WhereEq(&keyword.Group, "Keyword", "k").
+		WhereEq(&keyword.Order, 2, "k").
+		OrderBy(&keyword.User, builder.Asc, "k").
+		GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testKeyword " + "found err:" + err.Error())
+	}
+	if len(list) == 0 || list[0].User.String != "Alice" {
+		panic(db.DriverName() + " testKeyword " + "keyword column not match")
+	}
+
+	_, err = aorm.Db(db).Table(&keyword).WhereEq(&keyword.Id, id).Delete()
+	if err != nil {
+		panic(db.DriverName() + " testKeyword " + "found err:" + err.Error())
+	}
+}
+
+//customDialect 自定义方言,语法与内置数据库一致,只修改名字
+type customDialect struct {
+	driver.Dialect
+	name string
+}
+
+func (d customDialect) Name() string {
+	return d.name
+}
+
+//noFeatureDialect 自定义方言,不支持任何特性
+type noFeatureDialect struct {
+	customDialect
+}
+
+func (d noFeatureDialect) Supports(feature driver.Feature, version string) bool {
+	return false
+}
+
+//syntaxDialect 自定义方言,覆盖查询语句中 CAST 的类型
+type syntaxDialect struct {
+	customDialect
+	driver.QueryDialect
+}
+
+func (d syntaxDialect) CastType(typ string) string {
+	return "CUSTOM_" + typ
+}
+
+func testDialect(db *base.Db) {
+	name := db.DriverName() + "_custom"
+	driver.Register(name, customDialect{Dialect: driver.GetDialect(db.DriverName()), name: name})
+
+	if _, ok := driver.Lookup(name); !ok {
+		panic(db.DriverName() + " testDialect " + "dialect not registered")
+	}
+	if driver.GetDialect("unknown").Flavor() != "" {
+		panic(db.DriverName() + " testDialect " + "unknown dialect should use common dialect")
+	}
+
+	customDb := &base.Db{Driver: name, SqlDB: db.SqlDB}
+	err := aorm.Migrator(customDb).AutoMigrate(&keyword)
+	if err != nil {
+		panic(db.DriverName() + " testDialect " + "found err:" + err.Error())
+	}
+
+	id, err := aorm.Db(customDb).Insert(&Keyword{
+		Order: null.IntFrom(1),
+		Group: null.StringFrom("Dialect"),
+		User:  null.StringFrom("Bob"),
+	})
+	if err != nil {
+		panic(db.DriverName() + " testDialect " + "found err:" + err.Error())
+	}
+
+	var list []Keyword
+	err = aorm.Db(customDb).
+		Table(&keyword).
+		WhereEq(&keyword.Group, "Dialect").
+		WhereLike(&keyword.User, []string{"B", "%"}).
+		OrderBy(&keyword.Id, builder.Desc).
+		Limit(0, 1).
+		GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testDialect " + "found err:" + err.Error())
+	}
+	if len(list) != 1 || list[0].Id.Int64 != id {
+		panic(db.DriverName() + " testDialect " + "record not match")
+	}
+
+	_, err = aorm.Db(customDb).Table(&keyword).WhereEq(&keyword.Id, id).Delete()
+	if err != nil {
+		panic(db.DriverName() + " testDialect " + "found err:" + err.Error())
+	}
+
+	syntaxName := db.DriverName() + "_syntax"
+	custom := customDialect{Dialect: driver.GetDialect(db.DriverName()), name: syntaxName}
+	driver.Register(syntaxName, syntaxDialect{customDialect: custom, QueryDialect: driver.GetQueryDialect(custom)})
+
+	syntaxDb := &base.Db{Driver: syntaxName, SqlDB: db.SqlDB}
+	query, _, err := aorm.Db(syntaxDb).Table(&person).SelectAs(builder.Cast(builder.Col(&person.Money), builder.CastString), &personExpr.MoneyStr).GetSqlAndParams()
+	if err != nil {
+		panic(db.DriverName() + " testDialect " + "found err:" + err.Error())
+	}
+	if !strings.Contains(query, "CUSTOM_"+builder.CastString) {
+		panic(db.DriverName() + " testDialect " + "custom CastType not used:" + query)
+	}
+}
+
+func testOpenDB(db *base.Db) {
+	if driver.ResolveName("pgx") != driver.Postgres || driver.ResolveName("sqlite") != driver.Sqlite3 || driver.ResolveName("sqlserver") != driver.Mssql {
+		panic(db.DriverName() + " testOpenDB " + "driver alias not resolved")
+	}
+
+	_, err := aorm.OpenDB(db.SqlDB, "unknown")
+	if !errors.Is(err, aorm.ErrUnknownDialect) {
+		panic(db.DriverName() + " testOpenDB " + "should return ErrUnknownDialect")
+	}
+
+	_, err = aorm.OpenWithDialect(db.DriverName(), "", "unknown")
+	if !errors.Is(err, aorm.ErrUnknownDialect) {
+		panic(db.DriverName() + " testOpenDB " + "should return ErrUnknownDialect")
+	}
+
+	wrapDb, err := aorm.OpenDB(db.SqlDB, db.DriverName())
+	if err != nil {
+		panic(db.DriverName() + " testOpenDB " + "found err:" + err.Error())
+	}
+
+	var list []Keyword
+	err = aorm.Db(wrapDb).Table(&keyword).OrderBy(&keyword.Id, builder.Desc).Limit(0, 1).GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testOpenDB " + "found err:" + err.Error())
+	}
+}
+
+func testNamedParam(db *base.Db) {
+	id, err := aorm.Db(db).Insert(&Person{
+		Name: null.StringFrom("Named?"),
+		Age:  null.IntFrom(31),
+	})
+	if err != nil {
+		panic(db.DriverName() + " testNamedParam " + "found err:" + err.Error())
+	}
+
+	var list []Person
+	err = aorm.Db(db).RawSql("SELECT * FROM person WHERE id=:id AND age=@age AND name<>'what?'", builder.Named(map[string]interface{}{"id": id, "age": 31})).GetMany(&list)
+	if err != nil {
+		panic(db.DriverName() + " testNamedParam " + "found err:" + err.Error())
+	}
+	if len(list) != 1 || list[0].Name.String != "Named?" {
+		panic(db.DriverName() + " testNamedParam " + "map params not match")
+	}
+
+	var list2 []Person
+	err = aorm.Db(db).RawSql("SELECT * FROM person WHERE id=:id AND name=:Name -- ?", builder.NamedStruct(Person{Id: null.IntFrom(id), Name: null.StringFrom("Named?")})).GetMany(&list2)
+	if err != nil {
+		panic(db.DriverName() + " testNamedParam " + "found err:" + err.Error())
+	}
+	if len(list2) != 1 {
+		panic(db.DriverName() + " testNamedParam " + "struct params not match")
+	}
+
+	var list3 []Person
+	err = aorm.Db(db).
+		Table(&person).
+		WhereRaw("age > :min AND age < :max", builder.Named(map[string]interface{}{"min": 30, "max": 32})).
+		WhereRaw("name <> ?", "Bob").
+		WhereEq(&person.Id, id).
+		GetMany(&list3)
+	if err != nil {
+		panic(db.DriverName() + " testNamedParam " + "found err:" + err.Error())
+	}
+	if len(list3) != 1 {
+		panic(db.DriverName() + " testNamedParam " + "where raw params not match")
+	}
+
+	//原始sql作为子查询时,命名参数同样绑定
+	sub := aorm.Db(db).RawSql("SELECT id FROM person WHERE age=:age", builder.Named(map[string]interface{}{"age": 31}))
+	var list4 []Person
+	err = aorm.Db(db).Table(&person).WhereIn(&person.Id, &sub).WhereEq(&person.Id, id).GetMany(&list4)
+	if err != nil || len(list4) != 1 {
+		panic(db.DriverName() + " testNamedParam " + "sub query params not match")
+	}
+
+	//不是 Named 的参数按 ? 绑定,不会当作命名参数
+	var list5 []Person
+	err = aorm.Db(db).RawSql("SELECT * FROM person WHERE id=? AND name<>':id'", null.IntFrom(id)).GetMany(&list5)
+	if err != nil || len(list5) != 1 {
+		panic(db.DriverName() + " testNamedParam " + "positional params not match")
+	}
+
+	_, err = aorm.Db(db).RawSql("UPDATE person SET age=:age WHERE id=:id", builder.Named(map[string]interface{}{"age": 32})).Exec()
+	if !errors.Is(err, aorm.ErrMissingParam) {
+		panic(db.DriverName() + " testNamedParam " + "should return ErrMissingParam")
+	}
+
+	_, err = aorm.Db(db).Table(&person).WhereEq(&person.Id, id).Delete()
+	if err != nil {
+		panic(db.DriverName() + " testNamedParam " + "found err:" + err.Error())
+	}
+}
+
+func testPaginate(db *base.Db) {
+	var personList []*Person
+	for i := 0; i < 5; i++ {
+		personList = append(personList, &Person{
+			Name: null.StringFrom("Paginate"),
+			Age:  null.IntFrom(int64(40 + i%3)),
+		})
+	}
+	_, err := aorm.Db(db).InsertBatch(&personList)
+	if err != nil {
+		panic(db.DriverName() + " testPaginate " + "found err:" + err.Error())
+	}
+
+	var list []Person
+	pagination, err := aorm.Db(db).
+		Table(&person).
+		WhereEq(&person.Name, "Paginate").
+		OrderBy(&person.Id, builder.Asc).
+		Paginate(2, 2, &list)
+	if err != nil {
+		panic(db.DriverName() + " testPaginate " + "found err:" + err.Error())
+	}
+	if pagination.Total != 5 || pagination.Pages != 3 || pagination.Page != 2 || pagination.Size != 2 || len(list) != 2 {
+		panic(db.DriverName() + " testPaginate " + "pagination not match")
+	}
+
+	var ageList []PersonAge
+	pagination, err = aorm.Db(db).
+		Table(&person).
+		Select(&person.Age).
+		SelectCount(&person.Id, &personAge.AgeCount).
+		WhereEq(&person.Name, "Paginate").
+		GroupBy(&person.Age).
+		OrderBy(&person.Age, builder.Asc).
+		Paginate(1, 2, &ageList)
+	if err != nil {
+		panic(db.DriverName() + " testPaginate " + "found err:" + err.Error())
+	}
+	if pagination.Total != 3 || pagination.Pages != 2 || len(ageList) != 2 {
+		panic(db.DriverName() + " testPaginate " + "group pagination not match")
+	}
+
+	var list2 []Person
+	pagination, err = aorm.Db(db).
+		Table(&person).
+		WhereEq(&person.Name, "Paginate").
+		OrderBy(&person.Id, builder.Asc).
+		PaginateOver(3, 2, &list2)
+	if err != nil {
+		panic(db.DriverName() + " testPaginate " + "found err:" + err.Error())
+	}
+	if pagination.Total != 5 || pagination.Pages != 3 || len(list2) != 1 || list2[0].Name.String != "Paginate" {
+		panic(db.DriverName() + " testPaginate " + "pagination over not match")
+	}
+
+	var list3 []Person
+	pagination, err = aorm.Db(db).
+		Table(&person).
+		WhereEq(&person.Name, "Paginate").
+		OrderBy(&person.Id, builder.Asc).
+		PaginateOver(4, 2, &list3)
+	if err != nil {
+		panic(db.DriverName() + " testPaginate " + "found err:" + err.Error())
+	}
+	if pagination.Total != 5 || len(list3) != 0 {
+		panic(db.DriverName() + " testPaginate " + "empty page not match")
+	}
+
+	_, err = aorm.Db(db).Table(&person).Paginate(1, 0, &list3)
+	if !errors.Is(err, aorm.ErrInvalidPageSize) {
+		panic(db.DriverName() + " testPaginate " + "should return ErrInvalidPageSize")
+	}
+
+	_, err = aorm.Db(db).Table(&person).WhereEq(&person.Name, "Paginate").Delete()
+	if err != nil {
+		panic(db.DriverName() + " testPaginate " + "found err:" + err.Error())
+	}
+}
+
+func testCursorPaginate(db *base.Db) {
+	var personList []*Person
+	for i := 0; i < 5; i++ {
+		personList = append(personList, &Person{
+			Name: null.StringFrom("Cursor"),
+			Age:  null.IntFrom(int64(50 + i/2)),
+		})
+	}
+	_, err := aorm.Db(db).InsertBatch(&personList)
+	if err != nil {
+		panic(db.DriverName() + " testCursorPaginate " + "found err:" + err.Error())
+	}
+
+	//排序方向不一致,使用展开的条件
+	orderFields := []builder.OrderItem{{Field: &person.Age, OrderType: builder.Desc}, {Field: &person.Id, OrderType: builder.Asc}}
+
+	var ids []int64
+	after := ""
+	var pageList [][]Person
+	for i := 0; i < 3; i++ {
+		var list []Person
+		pagination, err := aorm.Db(db).Table(&person).WhereEq(&person.Name, "Cursor").CursorPaginate(orderFields, after, 2, &list)
+		if err != nil {
+			panic(db.DriverName() + " testCursorPaginate " + "found err:" + err.Error())
+		}
+		for j := 0; j < len(list); j++ {
+			ids = append(ids, list[j].Id.Int64)
+		}
+		pageList = append(pageList, list)
+		if (i < 2) != (pagination.Next != "") || (i > 0) != (pagination.Prev != "") {
+			panic(db.DriverName() + " testCursorPaginate " + "cursor not match")
+		}
+		after = pagination.Next
+		if i == 2 {
+			after = pagination.Prev
+		}
+	}
+	if len(ids) != 5 || pageList[0][0].Age.Int64 != 52 || pageList[2][0].Age.Int64 != 50 || pageList[2][0].Id.Int64 != ids[4] {
+		panic(db.DriverName() + " testCursorPaginate " + "records not match")
+	}
+
+	//从最后一页往前翻
+	var prevList []Person
+	pagination, err := aorm.Db(db).Table(&person).WhereEq(&person.Name, "Cursor").CursorPaginate(orderFields, after, 2, &prevList)
+	if err != nil {
+		panic(db.DriverName() + " testCursorPaginate " + "found err:" + err.Error())
+	}
+	if len(prevList) != 2 || prevList[0].Id.Int64 != ids[2] || prevList[1].Id.Int64 != ids[3] || pagination.Next == "" || pagination.Prev == "" {
+		panic(db.DriverName() + " testCursorPaginate " + "prev records not match")
+	}
+
+	//排序方向一致,使用行值比较
+	var ascList []Person
+	pagination, err = aorm.Db(db).
+		Table(&person).
+		WhereEq(&person.Name, "Cursor").
+		OrderBy(&person.Age, builder.Asc).
+		CursorPaginate([]builder.OrderItem{{Field: &person.Id, OrderType: builder.Asc}}, "", 3, &ascList)
+	if err != nil {
+		panic(db.DriverName() + " testCursorPaginate " + "found err:" + err.Error())
+	}
+	var ascList2 []Person
+	_, err = aorm.Db(db).
+		Table(&person).
+		WhereEq(&person.Name, "Cursor").
+		OrderBy(&person.Age, builder.Asc).
+		CursorPaginate([]builder.OrderItem{{Field: &person.Id, OrderType: builder.Asc}}, pagination.Next, 3, &ascList2)
+	if err != nil {
+		panic(db.DriverName() + " testCursorPaginate " + "found err:" + err.Error())
+	}
+	if len(ascList) != 3 || len(ascList2) != 2 || ascList2[1].Age.Int64 != 52 {
+		panic(db.DriverName() + " testCursorPaginate " + "asc records not match")
+	}
+
+	//条件中有 OR 时,游标条件不能与 OR 混在一起
+	var orIds []int64
+	after = ""
+	for i := 0; i < 5; i++ {
+		var list []Person
+		pagination, err = aorm.Db(db).
+			Table(&person).
+			WhereEq(&person.Name, "Cursor").
+			WhereOrEq(&person.Name, "Cursor Or").
+			CursorPaginate([]builder.OrderItem{{Field: &person.Id, OrderType: builder.Asc}}, after, 2, &list)
+		if err != nil {
+			panic(db.DriverName() + " testCursorPaginate " + "found err:" + err.Error())
+		}
+		for j := 0; j < len(list); j++ {
+			orIds = append(orIds, list[j].Id.Int64)
+		}
+		if pagination.Next == "" {
+			break
+		}
+		after = pagination.Next
+	}
+	if len(orIds) != 5 {
+		panic(db.DriverName() + " testCursorPaginate " + "records with OR not match")
+	}
+	for i := 1; i < len(orIds); i++ {
+		if orIds[i] <= orIds[i-1] {
+			panic(db.DriverName() + " testCursorPaginate " + "records with OR should not repeat")
+		}
+	}
+
+	_, err = aorm.Db(db).Table(&person).CursorPaginate([]builder.OrderItem{{Field: builder.RawExpr("age + 1"), OrderType: builder.Asc}}, "", 2, &ascList2)
+	if !errors.Is(err, aorm.ErrNotSupported) {
+		panic(db.DriverName() + " testCursorPaginate " + "should return ErrNotSupported for expression")
+	}
+
+	_, err = aorm.Db(db).Table(&person).CursorPaginate(orderFields, "not a cursor", 2, &ascList2)
+	if !errors.Is(err, aorm.ErrInvalidCursor) {
+		panic(db.DriverName() + " testCursorPaginate " + "should return ErrInvalidCursor")
+	}
+
+	_, err = aorm.Db(db).Table(&person).WhereEq(&person.Name, "Cursor").Delete()
+	if err != nil {
+		panic(db.DriverName() + " testCursorPaginate " + "found err:" + err.Error())
+	}
+}
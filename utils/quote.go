@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Quote 给标识符加上引号,schema.table 分段处理,例如 Quote("dbo.person", "[", "]") 得到 [dbo].[person]
+// 不是普通标识符的(表达式,带别名,函数等)原样返回,已加引号的部分与 * 不再处理
+func Quote(name string, open string, close string) string {
+	if !IsIdentifier(name) {
+		return name
+	}
+
+	parts := strings.Split(name, ".")
+	for i := 0; i < len(parts); i++ {
+		if parts[i] == "*" || isQuoted(parts[i]) {
+			continue
+		}
+		parts[i] = open + parts[i] + close
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// IsIdentifier 是否为普通标识符,允许以 . 分隔,最后一段可以是 *
+func IsIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	parts := strings.Split(name, ".")
+	for i := 0; i < len(parts); i++ {
+		if parts[i] == "*" && i == len(parts)-1 && i > 0 {
+			continue
+		}
+		if isQuoted(parts[i]) {
+			continue
+		}
+		if !isPlainIdentifier(parts[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+//isPlainIdentifier 字母或下划线开头,只包含字母,数字,下划线
+func isPlainIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for i, r := range s {
+		if r == '_' || unicode.IsLetter(r) {
+			continue
+		}
+		if i > 0 && (unicode.IsDigit(r) || r == '$') {
+			continue
+		}
+		return false
+	}
+
+	return true
+}
+
+//isQuoted 是否已经加了引号
+func isQuoted(s string) bool {
+	if len(s) < 2 {
+		return false
+	}
+
+	first, last := s[0], s[len(s)-1]
+	return (first == '`' && last == '`') || (first == '"' && last == '"') || (first == '[' && last == ']')
+}
+
+// Unquote 去掉标识符的引号,例如 "person"."id" 得到 person.id
+func Unquote(name string) string {
+	parts := strings.Split(name, ".")
+	for i := 0; i < len(parts); i++ {
+		if isQuoted(parts[i]) {
+			parts[i] = parts[i][1 : len(parts[i])-1]
+		}
+	}
+
+	return strings.Join(parts, ".")
+}
+
+// SplitTableName 拆分 schema.table,没有 schema 时返回空
+func SplitTableName(tableName string) (string, string) {
+	tableName = Unquote(tableName)
+	index := strings.LastIndex(tableName, ".")
+	if index == -1 {
+		return "", tableName
+	}
+
+	return tableName[:index], tableName[index+1:]
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+	tests := []struct {
+		name  string
+		open  string
+		close string
+		want  string
+	}{
+		{"person", "`", "`", "`person`"},
+		{"person", "\"", "\"", "\"person\""},
+		{"person", "[", "]", "[person]"},
+		{"dbo.person", "[", "]", "[dbo].[person]"},
+		{"p.*", "`", "`", "`p`.*"},
+		{"*", "`", "`", "*"},
+		{"`person`", "`", "`", "`person`"},
+		{"\"public\".person", "\"", "\"", "\"public\".\"person\""},
+		{"[dbo].person", "[", "]", "[dbo].[person]"},
+		{"_id2", "`", "`", "`_id2`"},
+		{"a$b", "`", "`", "`a$b`"},
+		{"名字", "`", "`", "`名字`"},
+		{"", "`", "`", ""},
+		{"2id", "`", "`", "2id"},
+		{"$id", "`", "`", "$id"},
+		{"count(*)", "`", "`", "count(*)"},
+		{"person p", "`", "`", "person p"},
+		{"a.b.c", "`", "`", "`a`.`b`.`c`"},
+		{"a..b", "`", "`", "a..b"},
+		{"a.", "`", "`", "a."},
+		{"*.a", "`", "`", "*.a"},
+		{"a+b", "`", "`", "a+b"},
+	}
+
+	for _, tt := range tests {
+		if got := Quote(tt.name, tt.open, tt.close); got != tt.want {
+			t.Fatalf("Quote(%q, %q, %q) = %q, want %q", tt.name, tt.open, tt.close, got, tt.want)
+		}
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"person", "person"},
+		{"`person`", "person"},
+		{"\"public\".\"person\"", "public.person"},
+		{"[dbo].[person]", "dbo.person"},
+		{"p.*", "p.*"},
+	}
+
+	for _, tt := range tests {
+		if got := Unquote(tt.name); got != tt.want {
+			t.Fatalf("Unquote(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
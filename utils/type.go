@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// TypeName 获取字段类型对应的通用类型名,支持 null.* sql.Null* 普通类型以及指针
+func TypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == timeType {
+		return "Time"
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "Int"
+	case reflect.Float32, reflect.Float64:
+		return "Float"
+	case reflect.String:
+		return "String"
+	case reflect.Bool:
+		return "Bool"
+	}
+
+	//null.Int sql.NullInt64 sql.NullTime 等类型
+	name := strings.TrimPrefix(t.Name(), "Null")
+	switch {
+	case strings.HasPrefix(name, "Int"), strings.HasPrefix(name, "Byte"):
+		return "Int"
+	case strings.HasPrefix(name, "Float"):
+		return "Float"
+	}
+
+	return name
+}